@@ -0,0 +1,75 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/soniah/gosnmp"
+)
+
+const (
+	dot1dBasePortTable = ".1.3.6.1.2.1.17.1.4"
+	dot1dTpFdbTable    = ".1.3.6.1.2.1.17.4.3"
+)
+
+// dot1dBasePortEntry and dot1dTpFdbEntry columns
+const (
+	dot1dBasePortIfIndex = 2
+	dot1dTpFdbPort       = 2
+)
+
+// FDBEntry is a learned MAC address of the bridge forwarding table
+type FDBEntry struct {
+	MAC        net.HardwareAddr
+	BridgePort int
+	IfIndex    int // 0 if the bridge port has no known interface
+}
+
+// ForwardingTable returns the bridge's forwarding table, with the
+// bridge ports mapped to interfaces using the dot1dBasePortTable
+func ForwardingTable(client *gosnmp.GoSNMP) ([]FDBEntry, error) {
+	ports, err := GetTable(client, dot1dBasePortTable)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := GetTable(client, dot1dTpFdbTable)
+	if err != nil {
+		return nil, err
+	}
+	return fdbEntries(rows, portIfIndexes(ports))
+}
+
+// portIfIndexes maps bridge ports to their ifIndex
+func portIfIndexes(rows map[string]map[int]interface{}) map[int]int {
+	ports := make(map[int]int, len(rows))
+	for index, row := range rows {
+		port, err := strconv.Atoi(index)
+		if err != nil {
+			continue
+		}
+		if ifIndex, ok := row[dot1dBasePortIfIndex].(int); ok {
+			ports[port] = ifIndex
+		}
+	}
+	return ports
+}
+
+// fdbEntries decodes the MAC address indexed forwarding table rows
+func fdbEntries(rows map[string]map[int]interface{}, ports map[int]int) ([]FDBEntry, error) {
+	entries := make([]FDBEntry, 0, len(rows))
+	for _, index := range sortedIndexes(rows) {
+		mac, _, err := indexOctets(index, 6)
+		if err != nil {
+			return nil, err
+		}
+		e := FDBEntry{MAC: net.HardwareAddr(mac)}
+		e.BridgePort, _ = rows[index][dot1dTpFdbPort].(int)
+		e.IfIndex = ports[e.BridgePort]
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+)
+
+func TestFDBEntries(t *testing.T) {
+	ports := portIfIndexes(map[string]map[int]interface{}{
+		"1": {dot1dBasePortIfIndex: 10101},
+		"2": {dot1dBasePortIfIndex: 10102},
+	})
+	rows := map[string]map[int]interface{}{
+		"0.80.86.171.205.239": {dot1dTpFdbPort: 2},
+		"0.27.33.1.2.3":       {dot1dTpFdbPort: 1},
+		"0.27.33.1.2.4":       {dot1dTpFdbPort: 7},
+	}
+	entries, err := fdbEntries(rows, ports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	e := entries[2]
+	if e.MAC.String() != "00:50:56:ab:cd:ef" || e.BridgePort != 2 || e.IfIndex != 10102 {
+		t.Errorf("unexpected entry: %s port %d ifIndex %d", e.MAC, e.BridgePort, e.IfIndex)
+	}
+	if entries[1].IfIndex != 0 {
+		t.Errorf("expected no ifIndex for unknown port, got %d", entries[1].IfIndex)
+	}
+	if _, err := fdbEntries(map[string]map[int]interface{}{"0.80.86": {}}, ports); err == nil {
+		t.Error("expected error for short index")
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// BulkWalkMixed fetches scalars and walks table columns in as few round
+// trips as possible. The scalars are sent as the non-repeaters of the
+// first GETBULK, and only the columns are requested again until each
+// has left its subtree. Scalars may be given with or without their .0
+// instance and are returned keyed with it.
+func BulkWalkMixed(client *gosnmp.GoSNMP, scalars, columns []string) (map[string]interface{}, map[string]interface{}, error) {
+	scalars, err := resolveOIDs(scalars)
+	if err != nil {
+		return nil, nil, err
+	}
+	if columns, err = resolveOIDs(columns); err != nil {
+		return nil, nil, err
+	}
+	if err := connected(client); err != nil {
+		return nil, nil, err
+	}
+	if client.Version == gosnmp.Version1 {
+		return mixedV1(client, scalars, columns)
+	}
+	s, c, err := bulkWalkMixed(client, client.MaxRepetitions, scalars, columns)
+	recordStats(client, err)
+	return s, c, err
+}
+
+// mixedV1 gets the scalars and walks the columns separately, as v1 has no GETBULK
+func mixedV1(client *gosnmp.GoSNMP, scalars, columns []string) (map[string]interface{}, map[string]interface{}, error) {
+	oids := make([]string, 0, len(scalars))
+	for _, oid := range scalars {
+		oids = append(oids, strings.TrimSuffix(oid, ".0")+".0")
+	}
+	scalarValues, _, err := Get(client, oids)
+	if err != nil && errors.Cause(err) != ErrPartialGet {
+		return nil, nil, err
+	}
+	columnValues := make(map[string]interface{})
+	for _, column := range columns {
+		values, err := Walk(client, column)
+		if err != nil {
+			return scalarValues, columnValues, err
+		}
+		for k, v := range values {
+			columnValues[k] = v
+		}
+	}
+	return scalarValues, columnValues, nil
+}
+
+func bulkWalkMixed(client bulkGetter, maxReps uint8, scalars, columns []string) (map[string]interface{}, map[string]interface{}, error) {
+	if maxReps == 0 {
+		maxReps = defaultRepetitions
+	}
+	if len(scalars) > 255 {
+		return nil, nil, errors.Errorf("too many scalars: %d", len(scalars))
+	}
+	scalarValues := make(map[string]interface{})
+	columnValues := make(map[string]interface{})
+
+	oids := make([]string, 0, len(scalars)+len(columns))
+	for _, oid := range scalars {
+		oids = append(oids, strings.TrimSuffix(oid, ".0"))
+	}
+	oids = append(oids, columns...)
+	nonRepeaters := len(scalars)
+
+	// the columns still being walked and the last OID seen of each
+	active := append([]string{}, columns...)
+	last := append([]string{}, columns...)
+
+	for len(oids) > 0 {
+		packet, err := client.GetBulk(oids, uint8(nonRepeaters), maxReps)
+		if err != nil {
+			return scalarValues, columnValues, err
+		}
+		if packet.Error != gosnmp.NoError {
+			return scalarValues, columnValues, errors.Errorf("bulk request failed: %s", packet.Error)
+		}
+		vars := packet.Variables
+		if len(vars) < nonRepeaters {
+			return scalarValues, columnValues, errors.Errorf("expected %d scalars, got %d varbinds", nonRepeaters, len(vars))
+		}
+		for i, pdu := range vars[:nonRepeaters] {
+			if pdu.Name != oids[i]+".0" || !validPDU(pdu) {
+				continue
+			}
+			if scalarValues[pdu.Name], err = pduType(pdu); err != nil {
+				return scalarValues, columnValues, err
+			}
+		}
+		vars = vars[nonRepeaters:]
+
+		done := make([]bool, len(active))
+		for i, pdu := range vars {
+			c := i % len(active)
+			if done[c] {
+				continue
+			}
+			if !validPDU(pdu) || !strings.HasPrefix(pdu.Name, active[c]+".") || compareOIDs(pdu.Name, last[c]) <= 0 {
+				done[c] = true
+				continue
+			}
+			if columnValues[pdu.Name], err = pduType(pdu); err != nil {
+				return scalarValues, columnValues, err
+			}
+			last[c] = pdu.Name
+		}
+		// a column with no values in the response has ended
+		if len(vars) < len(active) {
+			for c := len(vars); c < len(active); c++ {
+				done[c] = true
+			}
+		}
+
+		nonRepeaters = 0
+		oids = oids[:0]
+		nextActive, nextLast := active[:0], last[:0]
+		for c := range active {
+			if !done[c] {
+				nextActive = append(nextActive, active[c])
+				nextLast = append(nextLast, last[c])
+				oids = append(oids, last[c])
+			}
+		}
+		active, last = nextActive, nextLast
+	}
+	return scalarValues, columnValues, nil
+}
+
+// validPDU reports whether the pdu holds a value rather than an exception
+func validPDU(pdu gosnmp.SnmpPDU) bool {
+	switch pdu.Type {
+	case gosnmp.EndOfMibView, gosnmp.NoSuchObject, gosnmp.NoSuchInstance:
+		return false
+	}
+	return true
+}
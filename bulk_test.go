@@ -0,0 +1,63 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+// mixedAgent replies to bulk requests keyed by the non-repeaters and requested OIDs
+type mixedAgent map[string][]gosnmp.SnmpPDU
+
+func (a mixedAgent) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	return &gosnmp.SnmpPacket{}, nil
+}
+
+func (a mixedAgent) GetBulk(oids []string, nonRepeaters, maxRepetitions uint8) (*gosnmp.SnmpPacket, error) {
+	key := string('0'+nonRepeaters) + ":" + strings.Join(oids, ",")
+	return &gosnmp.SnmpPacket{Variables: a[key]}, nil
+}
+
+func TestBulkWalkMixed(t *testing.T) {
+	const (
+		sysDescr = ".1.3.6.1.2.1.1.1"
+		descr    = ".1.3.6.1.2.1.2.2.1.2"
+		mtu      = ".1.3.6.1.2.1.2.2.1.4"
+	)
+	str := func(oid, v string) gosnmp.SnmpPDU {
+		return gosnmp.SnmpPDU{Name: oid, Type: gosnmp.OctetString, Value: []byte(v)}
+	}
+	agent := mixedAgent{
+		"2:" + sysDescr + "," + sysName[:len(sysName)-2] + "," + descr + "," + mtu: {
+			str(sysDescr+".0", "router"),
+			str(sysName, "r1"),
+			str(descr+".1", "lo"), intPDU(mtu+".1", 65536),
+			str(descr+".2", "eth0"), intPDU(mtu+".2", 1500),
+		},
+		"0:" + descr + ".2," + mtu + ".2": {
+			str(descr+".3", "eth1"), intPDU(".1.3.6.1.2.1.2.2.1.5.1", 0),
+			intPDU(mtu+".1", 65536), intPDU(".1.3.6.1.2.1.2.2.1.5.2", 0),
+		},
+	}
+	scalars, columns, err := bulkWalkMixed(agent, 2, []string{sysDescr + ".0", sysName}, []string{descr, mtu})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectScalars := map[string]interface{}{sysDescr + ".0": "router", sysName: "r1"}
+	if !reflect.DeepEqual(scalars, expectScalars) {
+		t.Errorf("expected scalars %v, got %v", expectScalars, scalars)
+	}
+	expectColumns := map[string]interface{}{
+		descr + ".1": "lo", descr + ".2": "eth0", descr + ".3": "eth1",
+		mtu + ".1": 65536, mtu + ".2": 1500,
+	}
+	if !reflect.DeepEqual(columns, expectColumns) {
+		t.Errorf("expected columns %v, got %v", expectColumns, columns)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/soniah/gosnmp"
+)
+
+const (
+	// repetitions of the GETBULK used to measure a device's varbinds
+	calibrateProbe = 5
+
+	// approximate encoded size of a response without its varbinds
+	responseOverhead = 64
+)
+
+var (
+	// calibrated max-repetitions, by device (see capabilityKey) and target size
+	calibrated = make(map[string]uint8)
+	calMu      sync.Mutex
+)
+
+// CalibrateRepetitions sizes the GETBULK max-repetitions of walks so
+// responses are about targetBytes (e.g., 1400 to fit in a typical MTU).
+// The first walk of a device measures the size of its varbinds with a
+// small GETBULK, whose values begin the walk, and the repetitions chosen
+// are reused for later walks of the device with the same target. An
+// explicit MaxRepetitions wins.
+func CalibrateRepetitions(targetBytes int) WalkOption {
+	return func(o *walkOptions) {
+		o.calibrate = targetBytes
+	}
+}
+
+// ForgetCalibration discards the calibrated max-repetitions of the
+// client's device, so the next calibrated walk measures it again
+func ForgetCalibration(client *gosnmp.GoSNMP) {
+	forgetCalibration(capabilityKey(client))
+}
+
+func forgetCalibration(device string) {
+	calMu.Lock()
+	for key := range calibrated {
+		if strings.HasPrefix(key, device+"/") {
+			delete(calibrated, key)
+		}
+	}
+	calMu.Unlock()
+}
+
+// calibratedRepetitions returns the device's max-repetitions calibrated
+// for target, measuring them with a GETBULK of rootOID if not yet known.
+// The response to that GETBULK is returned to begin the walk with. The
+// repetitions are 0 (for the default) if the subtree is too small to
+// measure.
+func calibratedRepetitions(client bulkGetter, device, rootOID string, target int) (uint8, *gosnmp.SnmpPacket, error) {
+	key := device + "/" + strconv.Itoa(target)
+	calMu.Lock()
+	reps, ok := calibrated[key]
+	calMu.Unlock()
+	if ok {
+		return reps, nil, nil
+	}
+	packet, err := client.GetBulk([]string{rootOID}, 0, calibrateProbe)
+	if err != nil {
+		return 0, nil, err
+	}
+	size, count := 0, 0
+	for _, pdu := range packet.Variables {
+		if validPDU(pdu) {
+			size += encodedSize(pdu)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, packet, nil
+	}
+	fit := (target - responseOverhead) * count / size
+	switch {
+	case fit < 1:
+		fit = 1
+	case fit > math.MaxUint8:
+		fit = math.MaxUint8
+	}
+	reps = uint8(fit)
+	calMu.Lock()
+	calibrated[key] = reps
+	calMu.Unlock()
+	return reps, packet, nil
+}
+
+// primedGetter answers the first GETBULK of a walk with a response
+// already received
+type primedGetter struct {
+	bulkGetter
+	first *gosnmp.SnmpPacket
+}
+
+func (g *primedGetter) GetBulk(oids []string, nonRepeaters, maxRepetitions uint8) (*gosnmp.SnmpPacket, error) {
+	if packet := g.first; packet != nil {
+		g.first = nil
+		return packet, nil
+	}
+	return g.bulkGetter.GetBulk(oids, nonRepeaters, maxRepetitions)
+}
+
+// encodedSize approximates the BER encoded size of a varbind
+func encodedSize(pdu gosnmp.SnmpPDU) int {
+	// tag and length of the varbind, its name and value
+	const headers = 6
+	size := headers + oidSize(pdu.Name)
+	switch v := pdu.Value.(type) {
+	case []byte:
+		size += len(v)
+	case string:
+		size += oidSize(v)
+	case uint64:
+		size += 9
+	default:
+		size += 5
+	}
+	return size
+}
+
+// oidSize returns the BER encoded size of a dotted OID
+func oidSize(oid string) int {
+	arcs := strings.Split(strings.TrimPrefix(oid, "."), ".")
+	if len(arcs) < 2 {
+		return 1
+	}
+	// the first two arcs share an octet
+	size := 1
+	for _, arc := range arcs[2:] {
+		n, _ := strconv.ParseUint(arc, 10, 32)
+		for size++; n >= 0x80; n >>= 7 {
+			size++
+		}
+	}
+	return size
+}
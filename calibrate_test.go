@@ -0,0 +1,74 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestCalibrateRepetitions(t *testing.T) {
+	var column []gosnmp.SnmpPDU
+	for i := 1; i <= calibrateProbe; i++ {
+		column = append(column, gosnmp.SnmpPDU{Name: fmt.Sprintf("%s.%d", ifDescr, i), Type: gosnmp.OctetString, Value: []byte("ethernet/0")})
+	}
+	agent := &repsAgent{bulkAgent: bulkAgent{ifDescr: column}}
+	const key = "calibrate-test"
+	defer forgetCalibration(key)
+
+	// each varbind is 26 octets encoded
+	reps, probe, err := calibratedRepetitions(agent, key, ifDescr, 1400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := uint8((1400 - responseOverhead) / 26); reps != expect {
+		t.Errorf("expected %d repetitions, got %d", expect, reps)
+	}
+	if probe == nil || len(probe.Variables) != calibrateProbe {
+		t.Errorf("expected the probe's response, got %+v", probe)
+	}
+	if again, probe, _ := calibratedRepetitions(agent, key, ifDescr, 1400); again != reps || probe != nil || len(agent.reps) != 1 {
+		t.Errorf("expected the calibration to be reused, got %d after %d probes", again, len(agent.reps))
+	}
+	if agent.reps[0] != calibrateProbe {
+		t.Errorf("expected a probe of %d, got %d", calibrateProbe, agent.reps[0])
+	}
+
+	// each target size is calibrated separately
+	reps, _, err = calibratedRepetitions(agent, key, ifDescr, 10)
+	if err != nil || reps != 1 || len(agent.reps) != 2 {
+		t.Errorf("expected a new calibration of at least one repetition, got %d after %d probes: %v", reps, len(agent.reps), err)
+	}
+	if reps, _, _ := calibratedRepetitions(agent, key+"-empty", sysName, 1400); reps != 0 {
+		t.Errorf("expected no calibration of an empty subtree, got %d", reps)
+	}
+
+	// the walk begins with the probe's values rather than fetching them again
+	forgetCalibration(key)
+	walker := &repsAgent{bulkAgent: bulkAgent{
+		ifDescr:                    column,
+		column[len(column)-1].Name: {intPDU(ifOperStatus+".1", 1)},
+	}}
+	reps, probe, _ = calibratedRepetitions(walker, key, ifDescr, 1400)
+	count := 0
+	err = bulkWalk(&primedGetter{bulkGetter: walker, first: probe}, ifDescr, walkOptions{maxReps: reps}, func(gosnmp.SnmpPDU) error {
+		count++
+		return nil
+	})
+	if err != nil || count != calibrateProbe {
+		t.Errorf("expected %d values, got %d: %v", calibrateProbe, count, err)
+	}
+	if len(walker.reps) != 2 || walker.reps[1] != reps {
+		t.Errorf("expected the probe then one request of %d, got %v", reps, walker.reps)
+	}
+
+	for oid, size := range map[string]int{".1.3.6.1.4.1.2021": 7, ifDescr + ".1": 10, ".1.3": 1} {
+		if got := oidSize(oid); got != size {
+			t.Errorf("%s: expected %d octets, got %d", oid, size, got)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"encoding/hex"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+// capabilities probed by the package helpers
+const (
+	CapBulk         = "bulk"          // see SupportsBulk
+	CapHighCapacity = "high-capacity" // see HasHighCapacityCounters
+)
+
+// Capability is the result of probing a device for a feature
+type Capability struct {
+	Supported bool
+	Probed    time.Time
+}
+
+// DeviceProfile holds the capabilities discovered for a device, keyed
+// by name (e.g., CapBulk). Callers may add their own, such as supported MIBs.
+type DeviceProfile struct {
+	Capabilities map[string]Capability
+}
+
+// CapabilityCache stores device profiles, keyed by host:port and also
+// by engine ID for v3 devices
+type CapabilityCache interface {
+	Get(key string) (DeviceProfile, bool)
+	Put(key string, p DeviceProfile) error
+}
+
+var (
+	capCache CapabilityCache
+	capTTL   time.Duration
+	capMu    sync.Mutex
+)
+
+// SetCapabilityCache sets the cache consulted by helpers such as
+// SupportsBulk before probing a device. Capabilities probed more than
+// ttl ago are probed again; a ttl of 0 keeps them indefinitely.
+func SetCapabilityCache(cache CapabilityCache, ttl time.Duration) {
+	capMu.Lock()
+	capCache, capTTL = cache, ttl
+	capMu.Unlock()
+}
+
+// MemoryCapabilityCache is a CapabilityCache held in memory
+type MemoryCapabilityCache struct {
+	sync.Mutex
+	profiles map[string]DeviceProfile
+}
+
+// NewMemoryCapabilityCache returns an empty cache
+func NewMemoryCapabilityCache() *MemoryCapabilityCache {
+	return &MemoryCapabilityCache{profiles: make(map[string]DeviceProfile)}
+}
+
+// Get returns the profile saved for key
+func (c *MemoryCapabilityCache) Get(key string) (DeviceProfile, bool) {
+	c.Lock()
+	defer c.Unlock()
+	p, ok := c.profiles[key]
+	return p, ok
+}
+
+// Put saves the profile for key
+func (c *MemoryCapabilityCache) Put(key string, p DeviceProfile) error {
+	c.Lock()
+	c.profiles[key] = p
+	c.Unlock()
+	return nil
+}
+
+// capabilityKey identifies the client's device
+func capabilityKey(client *gosnmp.GoSNMP) string {
+	if usm, ok := client.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok && len(usm.AuthoritativeEngineID) > 0 {
+		return hex.EncodeToString([]byte(usm.AuthoritativeEngineID))
+	}
+	return net.JoinHostPort(client.Target, strconv.Itoa(int(client.Port)))
+}
+
+// cachedCapability returns the cached capability of the client's device,
+// calling probe and saving its result if not cached or expired
+func cachedCapability(client *gosnmp.GoSNMP, name string, probe func() (bool, error)) (bool, error) {
+	capMu.Lock()
+	cache, ttl := capCache, capTTL
+	capMu.Unlock()
+	if cache == nil {
+		return probe()
+	}
+	key := capabilityKey(client)
+	if p, ok := cache.Get(key); ok {
+		if c, ok := p.Capabilities[name]; ok && (ttl == 0 || time.Since(c.Probed) < ttl) {
+			return c.Supported, nil
+		}
+	}
+	supported, err := probe()
+	if err != nil {
+		return supported, err
+	}
+	keys := []string{key}
+	// the probe may have discovered the v3 engine, so the result is also
+	// saved for the engine, while new clients of the host still find it
+	if discovered := capabilityKey(client); discovered != key {
+		keys = append(keys, discovered)
+	}
+	probed := Capability{Supported: supported, Probed: time.Now()}
+	capMu.Lock()
+	defer capMu.Unlock()
+	for _, key := range keys {
+		p, _ := cache.Get(key)
+		caps := make(map[string]Capability, len(p.Capabilities)+1)
+		for k, v := range p.Capabilities {
+			caps[k] = v
+		}
+		caps[name] = probed
+		cache.Put(key, DeviceProfile{Capabilities: caps})
+	}
+	return supported, nil
+}
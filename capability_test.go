@@ -0,0 +1,75 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestCapabilityCache(t *testing.T) {
+	cache := NewMemoryCapabilityCache()
+	SetCapabilityCache(cache, time.Hour)
+	defer SetCapabilityCache(nil, 0)
+
+	client := &gosnmp.GoSNMP{Target: "10.0.0.1", Port: 161}
+	probes := 0
+	probe := func() (bool, error) {
+		probes++
+		return true, nil
+	}
+	for i := 0; i < 3; i++ {
+		ok, err := cachedCapability(client, CapBulk, probe)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Error("expected cached capability to be supported")
+		}
+	}
+	if probes != 1 {
+		t.Errorf("expected 1 probe, got %d", probes)
+	}
+	if _, err := cachedCapability(client, CapHighCapacity, probe); err != nil {
+		t.Fatal(err)
+	}
+	p, ok := cache.Get("10.0.0.1:161")
+	if !ok || len(p.Capabilities) != 2 {
+		t.Errorf("expected 2 capabilities saved, got %+v", p)
+	}
+
+	// expired capabilities are probed again
+	p.Capabilities[CapBulk] = Capability{Supported: true, Probed: time.Now().Add(-2 * time.Hour)}
+	cache.Put("10.0.0.1:161", p)
+	cachedCapability(client, CapBulk, probe)
+	if probes != 3 {
+		t.Errorf("expected expired capability to be probed, got %d probes", probes)
+	}
+
+	client.SecurityParameters = &gosnmp.UsmSecurityParameters{AuthoritativeEngineID: "\x80\x00\x1f\x88\x04"}
+	if key := capabilityKey(client); key != "80001f8804" {
+		t.Errorf("expected engine ID key, got %s", key)
+	}
+
+	// a v3 client discovering its engine in the probe is found by a
+	// fresh client of the host, and by the engine
+	usm := &gosnmp.UsmSecurityParameters{}
+	v3 := &gosnmp.GoSNMP{Target: "10.0.0.2", Port: 161, SecurityParameters: usm}
+	discover := func() (bool, error) {
+		probes++
+		usm.AuthoritativeEngineID = "\x80\x00\x1f\x88\x05"
+		return true, nil
+	}
+	probes = 0
+	cachedCapability(v3, CapBulk, discover)
+	fresh := &gosnmp.GoSNMP{Target: "10.0.0.2", Port: 161, SecurityParameters: &gosnmp.UsmSecurityParameters{}}
+	cachedCapability(fresh, CapBulk, discover)
+	cachedCapability(v3, CapBulk, discover)
+	if probes != 1 {
+		t.Errorf("expected 1 probe of the v3 device, got %d", probes)
+	}
+}
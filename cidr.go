@@ -0,0 +1,70 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxCIDRHosts is the most hosts ExpandCIDR will return, a /16
+const DefaultMaxCIDRHosts = 1 << 16
+
+// ErrCIDRTooLarge is returned when a CIDR has more hosts than allowed
+var ErrCIDRTooLarge = errors.New("too many hosts in CIDR")
+
+// ExpandCIDR returns a copy of p for each host address in cidr (e.g.,
+// "10.1.2.0/24"), in address order, for use with PollHosts. For IPv4 the
+// network and broadcast addresses are skipped, except in a /31 or /32.
+// Ranges of more than DefaultMaxCIDRHosts fail with ErrCIDRTooLarge;
+// use ExpandCIDRLimit to scan larger ones.
+func ExpandCIDR(cidr string, p Profile) ([]Profile, error) {
+	return ExpandCIDRLimit(cidr, p, DefaultMaxCIDRHosts)
+}
+
+// ExpandCIDRLimit is ExpandCIDR allowing up to max hosts
+func ExpandCIDRLimit(cidr string, p Profile, max int) ([]Profile, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid CIDR %s", cidr)
+	}
+	ip := network.IP
+	ones, bits := network.Mask.Size()
+	hostBits := uint(bits - ones)
+	if hostBits >= 31 || max < 1 || 1<<hostBits > max+2 {
+		return nil, errors.Wrapf(ErrCIDRTooLarge, "%s exceeds %d hosts", cidr, max)
+	}
+	count := 1 << hostBits
+	skip := ip.To4() != nil && hostBits > 1
+	if skip {
+		// network and broadcast
+		count -= 2
+		ip = nextIP(ip)
+	}
+	if count > max {
+		return nil, errors.Wrapf(ErrCIDRTooLarge, "%s exceeds %d hosts", cidr, max)
+	}
+	profiles := make([]Profile, count)
+	for i := range profiles {
+		profiles[i] = p
+		profiles[i].Host = ip.String()
+		ip = nextIP(ip)
+	}
+	return profiles, nil
+}
+
+// nextIP returns the address following ip
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestExpandCIDR(t *testing.T) {
+	hosts := func(profiles []Profile) []string {
+		h := make([]string, len(profiles))
+		for i, p := range profiles {
+			h[i] = p.Host
+		}
+		return h
+	}
+	for _, test := range []struct {
+		cidr  string
+		count int
+		first string
+		last  string
+	}{
+		{"10.1.2.0/24", 254, "10.1.2.1", "10.1.2.254"},
+		{"10.1.2.77/30", 2, "10.1.2.77", "10.1.2.78"},
+		{"10.1.2.4/31", 2, "10.1.2.4", "10.1.2.5"},
+		{"10.1.2.3/32", 1, "10.1.2.3", "10.1.2.3"},
+		{"10.1.0.0/23", 510, "10.1.0.1", "10.1.1.254"},
+		{"fd00::/126", 4, "fd00::", "fd00::3"},
+	} {
+		profiles, err := ExpandCIDR(test.cidr, profileV2)
+		if err != nil {
+			t.Errorf("%s: %v", test.cidr, err)
+			continue
+		}
+		h := hosts(profiles)
+		if len(h) != test.count || h[0] != test.first || h[len(h)-1] != test.last {
+			t.Errorf("%s: expected %d hosts %s to %s, got %d %s to %s", test.cidr, test.count, test.first, test.last, len(h), h[0], h[len(h)-1])
+		}
+		if profiles[0].Community != profileV2.Community {
+			t.Errorf("%s: profile not copied: %+v", test.cidr, profiles[0])
+		}
+	}
+
+	if _, err := ExpandCIDR("bogus", profileV2); err == nil {
+		t.Error("expected error for invalid CIDR")
+	}
+	for _, cidr := range []string{"10.0.0.0/8", "fd00::/64", "0.0.0.0/0"} {
+		if _, err := ExpandCIDR(cidr, profileV2); errors.Cause(err) != ErrCIDRTooLarge {
+			t.Errorf("%s: expected ErrCIDRTooLarge, got %v", cidr, err)
+		}
+	}
+	if _, err := ExpandCIDR("10.1.0.0/16", profileV2); err != nil {
+		t.Errorf("a /16 should be allowed: %v", err)
+	}
+	profiles, err := ExpandCIDRLimit("10.0.0.0/14", profileV2, 1<<18)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 1<<18-2 {
+		t.Errorf("expected %d hosts, got %d", 1<<18-2, len(profiles))
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/soniah/gosnmp"
+)
+
+// Kind is what an OID refers to, as determined by Classify
+type Kind int
+
+// kinds of OIDs
+const (
+	KindUnknown Kind = iota
+	KindScalar
+	KindTable
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindScalar:
+		return "scalar"
+	case KindTable:
+		return "table"
+	}
+	return "unknown"
+}
+
+// Classify reports whether oid is a scalar or a table, judging by the
+// first value the agent has under it. A scalar's value is its .0
+// instance, while a table's is an instance of the first column of its
+// entry (oid.1.column.index). Anything else, including groups of
+// objects and OIDs with nothing under them, is KindUnknown.
+//
+// Where the MIBs are loaded (see LoadMIBs), the object the value is an
+// instance of decides. Otherwise an index ending in .0, which could as
+// well be a scalar within a group (e.g., system.sysDescr.0), is taken
+// as a table's only when the next column starts with the same index.
+func Classify(client *gosnmp.GoSNMP, oid string) (Kind, error) {
+	oid, err := getOID(oid)
+	if err != nil {
+		return KindUnknown, err
+	}
+	if err := connected(client); err != nil {
+		return KindUnknown, err
+	}
+	next, err := firstUnder(client, oid)
+	if err != nil || next == "" {
+		return KindUnknown, err
+	}
+	kind := classifyNext(oid, next)
+	if kind != KindUnknown {
+		return kind, nil
+	}
+	if _, _, ok := mibObject(next); ok {
+		return kind, nil
+	}
+	col, index, ok := entryIndex(oid, next)
+	if !ok || !strings.HasSuffix("."+index, ".0") {
+		return kind, nil
+	}
+	column := oid + ".1." + strconv.Itoa(col+1)
+	after, err := firstUnder(client, column)
+	if err != nil {
+		return KindUnknown, err
+	}
+	if after == column+"."+index {
+		return KindTable, nil
+	}
+	return KindUnknown, nil
+}
+
+// firstUnder returns the OID following oid, if the agent has one
+func firstUnder(client *gosnmp.GoSNMP, oid string) (string, error) {
+	packet, err := client.GetNext([]string{oid})
+	if err == nil {
+		err = packetError(client, packet)
+	}
+	recordStats(client, err)
+	if err != nil {
+		return "", err
+	}
+	if packet.Error != gosnmp.NoError || len(packet.Variables) != 1 {
+		return "", nil
+	}
+	pdu := packet.Variables[0]
+	switch pdu.Type {
+	case gosnmp.EndOfMibView, gosnmp.NoSuchObject, gosnmp.NoSuchInstance:
+		return "", nil
+	}
+	return pdu.Name, nil
+}
+
+// classifyNext classifies oid by the OID following it
+func classifyNext(oid, next string) Kind {
+	if !strings.HasPrefix(next, oid+".") {
+		return KindUnknown
+	}
+	if base, _, ok := mibObject(next); ok {
+		switch col, _, ok := entryIndex(oid, next); {
+		case base == oid && next == oid+".0":
+			return KindScalar
+		case ok && base == oid+".1."+strconv.Itoa(col) && next != base:
+			return KindTable
+		}
+		return KindUnknown
+	}
+	if next == oid+".0" {
+		return KindScalar
+	}
+	if _, index, ok := entryIndex(oid, next); ok && !strings.HasSuffix("."+index, ".0") {
+		// a trailing .0 may be a scalar within a group, which
+		// Classify tells apart from a table's row
+		return KindTable
+	}
+	return KindUnknown
+}
+
+// entryIndex splits next, an instance of oid.1.column.index, into its
+// column and index
+func entryIndex(oid, next string) (int, string, bool) {
+	sub := strings.SplitN(strings.TrimPrefix(next, oid+"."), ".", 3)
+	if !strings.HasPrefix(next, oid+".") || len(sub) != 3 || sub[0] != "1" {
+		return 0, "", false
+	}
+	col, err := strconv.Atoi(sub[1])
+	if err != nil {
+		return 0, "", false
+	}
+	return col, sub[2], true
+}
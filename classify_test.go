@@ -0,0 +1,101 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestClassifyNext(t *testing.T) {
+	for _, tc := range []struct {
+		oid, next string
+		kind      Kind
+	}{
+		{".1.3.6.1.2.1.1.1", ".1.3.6.1.2.1.1.1.0", KindScalar},
+		{".1.3.6.1.2.1.2.2", ".1.3.6.1.2.1.2.2.1.1.1", KindTable},
+		{".1.3.6.1.2.1.4.24.4", ".1.3.6.1.2.1.4.24.4.1.1.10.0.0.0.255.0.0.0.0.10.0.0.1", KindTable},
+		{".1.3.6.1.2.1.1", ".1.3.6.1.2.1.1.1.0", KindUnknown},
+		{".1.3.6.1.2", ".1.3.6.1.2.1.1.1.0", KindUnknown},
+		{".1.3.6.1.2.1.99", ".1.3.6.1.2.1.100.1.0", KindUnknown},
+		{ipRouteTable, ipRouteTable + ".1.1.0.0.0.0", KindUnknown},
+	} {
+		if kind := classifyNext(tc.oid, tc.next); kind != tc.kind {
+			t.Errorf("%s: expected %s, got %s", tc.oid, tc.kind, kind)
+		}
+	}
+}
+
+func TestClassifyNextMIB(t *testing.T) {
+	const ipRouteDest = ipRouteTable + ".1.1"
+	oidReader(MibInfo{Name: "RFC1213-MIB::ipRouteDest", OID: ipRouteDest, Syntax: "IpAddress"})
+	oidReader(MibInfo{Name: "SNMPv2-MIB::sysDescr", OID: ".1.3.6.1.2.1.1.1", Syntax: "DisplayString"})
+	defer func() {
+		delete(oidBase, ipRouteDest)
+		delete(oidBase, ".1.3.6.1.2.1.1.1")
+		delete(lookupOID, "ipRouteDest")
+		delete(lookupOID, "sysDescr")
+	}()
+	for _, tc := range []struct {
+		oid, next string
+		kind      Kind
+	}{
+		{ipRouteTable, ipRouteDest + ".0.0.0.0", KindTable},
+		{".1.3.6.1.2.1.1.1", ".1.3.6.1.2.1.1.1.0", KindScalar},
+		{".1.3.6.1.2", ".1.3.6.1.2.1.1.1.0", KindUnknown},
+		{".1.3.6.1.2.1.1", ".1.3.6.1.2.1.1.1.0", KindUnknown},
+	} {
+		if kind := classifyNext(tc.oid, tc.next); kind != tc.kind {
+			t.Errorf("%s: expected %s, got %s", tc.oid, tc.kind, kind)
+		}
+	}
+}
+
+func TestClassifyConfirm(t *testing.T) {
+	const defaultRoute = ".0.0.0.0"
+	for _, tc := range []struct {
+		after string
+		kind  Kind
+	}{
+		{ipRouteTable + ".1.2" + defaultRoute, KindTable},
+		{ipRouteTable + ".1.2.1.0", KindUnknown},
+	} {
+		client := replayAgent(t,
+			Exchange{
+				PDUType:  gosnmp.GetNextRequest,
+				OIDs:     []string{ipRouteTable},
+				Response: []Record{{OID: ipRouteTable + ".1.1" + defaultRoute, Type: int32(gosnmp.IPAddress), StrVal: "0.0.0.0"}},
+			},
+			Exchange{
+				PDUType:  gosnmp.GetNextRequest,
+				OIDs:     []string{ipRouteTable + ".1.2"},
+				Response: []Record{{OID: tc.after, Type: int32(gosnmp.Integer), IntVal: 1}},
+			},
+		)
+		kind, err := Classify(client, ipRouteTable)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kind != tc.kind {
+			t.Errorf("%s: expected %s, got %s", tc.after, tc.kind, kind)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	client := replayAgent(t, Exchange{
+		PDUType:  gosnmp.GetNextRequest,
+		OIDs:     []string{sysName[:len(sysName)-2]},
+		Response: []Record{{OID: sysName, Type: int32(gosnmp.OctetString), BytesVal: []byte("router1")}},
+	})
+	kind, err := Classify(client, sysName[:len(sysName)-2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind != KindScalar {
+		t.Errorf("expected scalar, got %s", kind)
+	}
+}
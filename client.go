@@ -5,27 +5,187 @@
 package snmputil
 
 import (
+	"fmt"
+	"log"
+	"math"
 	"net"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/soniah/gosnmp"
 )
 
+// DefaultRetries is the customary number of retries per request, for
+// Profile.Retries
+const DefaultRetries = 3
+
 const (
 	defaultPort = 161
+	maxRetries  = 10
+
+	// GETBULK max-repetitions when neither the walk nor the Profile sets it
+	defaultRepetitions = 20
+
+	// v1 agents often silently drop large requests
+	defaultOidsV1 = 16
+	maxOidsV1     = 32
+	sysUpTime     = ".1.3.6.1.2.1.1.3.0"
+
+	// the least time for the two round trips of v3 engine discovery and
+	// the first request to complete on a slow link
+	minV3Timeout = 2 * time.Second
+)
+
+var (
+	// the last community found to work for a host
+	communities = make(map[string]string)
+	cmu         sync.Mutex
+
+	// out of range retries are only logged the first time
+	clampOnce sync.Once
 )
 
 // Profile contains the settings needed to establish an SNMP connection
 type Profile struct {
 	Host, Community, Version string
-	Port, Timeout, Retries   int
+	Port, Timeout            int
+	// retries per request, 0 for none (see DefaultRetries)
+	Retries int
+	// for SNMP v1/v2c, alternate communities to try in order
+	Communities []string
+	// maximum OIDs per GET (0 for a version appropriate default)
+	MaxOids int
+	// GETBULK max-repetitions for walks (0 for the default)
+	MaxRepetitions int
+	// resolve the host only to "ip4" or "ip6" addresses ("" for either)
+	AddressFamily string
+	// how walks are made: "auto" (or ""), "bulk" or "getnext" (see SetWalkStrategy)
+	WalkStrategy string
 	// for SNMP v3
 	SecLevel, AuthUser, AuthPass, AuthProto, PrivProto, PrivPass string
+	// optional fixed authoritative engine (hex ID, see ParseEngineID), bypasses discovery
+	AuthEngineID                    string
+	AuthEngineBoots, AuthEngineTime int
+	// optional context engine (hex ID) for the scoped PDU, e.g., when proxying
+	ContextEngineID string
+	// optional context name for the scoped PDU, e.g., "vlan-10"
+	ContextName string
+}
+
+// V3Creds are the SNMPv3 credentials for a host
+type V3Creds struct {
+	SecLevel, AuthUser, AuthPass, AuthProto, PrivProto, PrivPass string
+}
+
+// CredentialProvider supplies credentials that are not kept in a Profile
+type CredentialProvider interface {
+	Community(host string) (string, error)
+	V3(host string) (V3Creds, error)
+}
+
+// ClientOption modifies how NewClient creates a client
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	creds     CredentialProvider
+	lazy      bool
+	dns       *DNSCache
+	limit     *TargetLimiter
+	filter    *OIDFilter
+	configure func(*gosnmp.GoSNMP)
+}
+
+// LazyConnect defers connecting until the first request made through the
+// package helpers, so NewClient succeeds even if the host is unreachable.
+// If alternate communities are given the first is used without probing.
+func LazyConnect() ClientOption {
+	return func(o *clientOptions) {
+		o.lazy = true
+	}
+}
+
+// WithCredentials resolves credentials missing from the Profile using cp
+func WithCredentials(cp CredentialProvider) ClientOption {
+	return func(o *clientOptions) {
+		o.creds = cp
+	}
+}
+
+// WithDNSCache resolves the host using the cache rather than on every connect
+func WithDNSCache(c *DNSCache) ClientOption {
+	return func(o *clientOptions) {
+		o.dns = c
+	}
+}
+
+// WithTargetLimiter caps the in-flight requests of a Session to its host using l
+func WithTargetLimiter(l *TargetLimiter) ClientOption {
+	return func(o *clientOptions) {
+		o.limit = l
+	}
+}
+
+// WithOIDFilter restricts the gets and walks of a Session to the subtrees f permits
+func WithOIDFilter(f *OIDFilter) ClientOption {
+	return func(o *clientOptions) {
+		o.filter = f
+	}
+}
+
+// Configure calls fn with the client before it connects (or, with
+// LazyConnect, before NewClient returns it), to set gosnmp options the
+// Profile doesn't expose. It runs after the version, security, timeout
+// and other Profile settings have been applied, so it may override them.
+// Alternate communities are still probed after it runs.
+func Configure(fn func(*gosnmp.GoSNMP)) ClientOption {
+	return func(o *clientOptions) {
+		o.configure = fn
+	}
+}
+
+// resolveCredentials fills in credentials missing from the profile
+func (o clientOptions) resolveCredentials(p *Profile) error {
+	if o.creds == nil {
+		return nil
+	}
+	switch p.Version {
+	case "3":
+		if len(p.AuthUser) > 0 {
+			return nil
+		}
+		c, err := o.creds.V3(p.Host)
+		if err != nil {
+			return errors.Wrapf(err, "v3 credentials for host %s", p.Host)
+		}
+		p.SecLevel, p.AuthUser, p.AuthPass = c.SecLevel, c.AuthUser, c.AuthPass
+		p.AuthProto, p.PrivProto, p.PrivPass = c.AuthProto, c.PrivProto, c.PrivPass
+	default:
+		if len(p.Community) > 0 || len(p.Communities) > 0 {
+			return nil
+		}
+		c, err := o.creds.Community(p.Host)
+		if err != nil {
+			return errors.Wrapf(err, "community for host %s", p.Host)
+		}
+		p.Community = c
+	}
+	return nil
 }
 
-// newClient returns an snmp client that has connected to an snmp agent
-func newClient(p Profile) (*gosnmp.GoSNMP, error) {
+// NewClient returns an snmp client that has connected to an snmp agent.
+// The client is not safe for concurrent use; use a Session to share one
+// across goroutines.
+func NewClient(p Profile, opts ...ClientOption) (*gosnmp.GoSNMP, error) {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.resolveCredentials(&p); err != nil {
+		return nil, err
+	}
+
 	var ok bool
 	var aProto gosnmp.SnmpV3AuthProtocol
 	var pProto gosnmp.SnmpV3PrivProtocol
@@ -96,7 +256,37 @@ func newClient(p Profile) (*gosnmp.GoSNMP, error) {
 		}
 	}
 
-	_, err := net.LookupHost(p.Host)
+	// pin the authoritative engine rather than discovering it
+	v3engine := func(usm *gosnmp.UsmSecurityParameters) error {
+		if len(p.AuthEngineID) == 0 {
+			if p.AuthEngineBoots != 0 || p.AuthEngineTime != 0 {
+				return errors.Errorf("engine boots/time require an engine ID for host %s", p.Host)
+			}
+			return nil
+		}
+		id, err := ParseEngineID(p.AuthEngineID)
+		if err != nil {
+			return errors.Wrapf(err, "invalid engine ID for host %s", p.Host)
+		}
+		usm.AuthoritativeEngineID = string(id)
+		usm.AuthoritativeEngineBoots = uint32(p.AuthEngineBoots)
+		usm.AuthoritativeEngineTime = uint32(p.AuthEngineTime)
+		return nil
+	}
+
+	network, err := addressFamily(p.AddressFamily)
+	if err != nil {
+		return nil, errors.Wrapf(err, "host %s", p.Host)
+	}
+	target := p.Host
+	switch {
+	case o.dns != nil:
+		target, err = o.dns.resolve(network, p.Host)
+	case len(p.AddressFamily) > 0:
+		target, err = resolveHost(network, p.Host)
+	default:
+		_, err = net.LookupHost(p.Host)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -104,13 +294,27 @@ func newClient(p Profile) (*gosnmp.GoSNMP, error) {
 	if p.Port == 0 {
 		p.Port = defaultPort
 	}
+	retries, ok := clampRetries(p.Retries)
+	if !ok {
+		clampOnce.Do(func() {
+			logf("clamping retries for host %s from %d to %d (not logged again)\n", p.Host, p.Retries, retries)
+		})
+	}
+	p.Retries = retries
 
 	client := &gosnmp.GoSNMP{
-		Target:  p.Host,
+		Target:  target,
 		Port:    uint16(p.Port),
 		Timeout: time.Duration(p.Timeout) * time.Second,
 		Retries: p.Retries,
 	}
+	// constrain the dial to the family too
+	switch network {
+	case "ip4":
+		client.Transport = "udp4"
+	case "ip6":
+		client.Transport = "udp6"
+	}
 
 	switch p.Version {
 	case "1":
@@ -124,6 +328,21 @@ func newClient(p Profile) (*gosnmp.GoSNMP, error) {
 		if err != nil {
 			return nil, err
 		}
+		if err := v3engine(usmParams); err != nil {
+			return nil, err
+		}
+		// keyed by target as checkEngine saves it, the address if resolved
+		if saved, ok := cachedEngine(client.Target, usmParams); ok && !saved.IsZero() {
+			clockOf(client).note(usmParams.AuthoritativeEngineBoots, usmParams.AuthoritativeEngineTime, saved)
+		}
+		if len(p.ContextEngineID) > 0 {
+			id, err := ParseEngineID(p.ContextEngineID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid context engine ID for host %s", p.Host)
+			}
+			client.ContextEngineID = string(id)
+		}
+		client.ContextName = p.ContextName
 		client.MsgFlags = msgFlags
 		client.SecurityModel = gosnmp.UserSecurityModel
 		client.SecurityParameters = usmParams
@@ -132,9 +351,150 @@ func newClient(p Profile) (*gosnmp.GoSNMP, error) {
 		return nil, errors.New("invalid snmp version")
 	}
 
+	if client.MaxOids, err = maxOids(client.Version, p.MaxOids); err != nil {
+		return nil, errors.Wrapf(err, "host %s", p.Host)
+	}
+
+	switch {
+	case p.MaxRepetitions == 0:
+		client.MaxRepetitions = defaultRepetitions
+	case p.MaxRepetitions < 0 || p.MaxRepetitions > math.MaxUint8:
+		return nil, errors.Errorf("invalid MaxRepetitions %d for host %s", p.MaxRepetitions, p.Host)
+	default:
+		client.MaxRepetitions = uint8(p.MaxRepetitions)
+	}
+
+	if err := SetWalkStrategy(client, p.WalkStrategy); err != nil {
+		return nil, errors.Wrapf(err, "host %s", p.Host)
+	}
+
 	if snmpLogger != nil {
 		client.Logger = snmpLogger
 	}
 
-	return client, client.Connect()
+	if o.lazy && len(p.Community) == 0 && len(p.Communities) > 0 {
+		client.Community = p.Communities[0]
+	}
+	if o.configure != nil {
+		o.configure(client)
+	}
+	// judged after Configure, which may change the timeout or retries
+	if warning := v3TimeoutWarning(client); len(warning) > 0 {
+		logf("host %s: %s\n", p.Host, warning)
+	}
+	if o.lazy {
+		return client, nil
+	}
+	if err := client.Connect(); err != nil {
+		return client, err
+	}
+	if len(p.Communities) > 0 && client.Version != gosnmp.Version3 {
+		if err := tryCommunities(client, p); err != nil {
+			client.Conn.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// connected ensures a lazily created client has connected
+func connected(client *gosnmp.GoSNMP) error {
+	if client.Conn != nil {
+		return nil
+	}
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	watchRetries(client)
+	return nil
+}
+
+// maxOids validates the OIDs per request for the version, 0 meaning the default
+func maxOids(version gosnmp.SnmpVersion, n int) (int, error) {
+	switch {
+	case n < 0:
+		return 0, errors.Errorf("invalid MaxOids: %d", n)
+	case version == gosnmp.Version1 && n > maxOidsV1:
+		return 0, errors.Errorf("MaxOids %d exceeds the limit of %d for SNMP v1", n, maxOidsV1)
+	case n > 0:
+		return n, nil
+	case version == gosnmp.Version1:
+		return defaultOidsV1, nil
+	}
+	return gosnmp.MaxOids, nil
+}
+
+// clampRetries bounds retries to a sane range.
+// It returns false if an out of range value was adjusted.
+func clampRetries(retries int) (int, bool) {
+	switch {
+	case retries < 0:
+		return 0, false
+	case retries > maxRetries:
+		return maxRetries, false
+	}
+	return retries, true
+}
+
+// v3TimeoutWarning returns advice if the client's timeout and retries are
+// too short for it to discover its v3 engine and make its first request,
+// failures that are easily mistaken for bad credentials
+func v3TimeoutWarning(client *gosnmp.GoSNMP) string {
+	if client.Version != gosnmp.Version3 || client.Timeout <= 0 {
+		return ""
+	}
+	if usm, ok := client.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok && len(usm.AuthoritativeEngineID) > 0 {
+		// the engine is known, so no discovery is needed
+		return ""
+	}
+	total := client.Timeout * time.Duration(client.Retries+1)
+	if total >= minV3Timeout {
+		return ""
+	}
+	return fmt.Sprintf("timeout of %s with %d retries allows only %s for SNMPv3 engine discovery and the request (two round trips), "+
+		"failures may look like bad credentials; use a timeout of at least %s or more retries",
+		client.Timeout, client.Retries, total, minV3Timeout)
+}
+
+// logf logs to the debug logger if set, otherwise the standard logger
+func logf(format string, v ...interface{}) {
+	if snmpLogger != nil {
+		snmpLogger.Printf(format, v...)
+		return
+	}
+	log.Printf(format, v...)
+}
+
+// tryCommunities sets the client's community to the first that the
+// host responds to. The community last found to work is tried first.
+func tryCommunities(client *gosnmp.GoSNMP, p Profile) error {
+	tries := make([]string, 0, len(p.Communities)+2)
+	cmu.Lock()
+	if c, ok := communities[p.Host]; ok {
+		tries = append(tries, c)
+	}
+	cmu.Unlock()
+	if len(p.Community) > 0 {
+		tries = append(tries, p.Community)
+	}
+	tries = append(tries, p.Communities...)
+
+	tried := make(map[string]struct{})
+	failed := []string{}
+	for _, community := range tries {
+		if _, ok := tried[community]; ok {
+			continue
+		}
+		tried[community] = struct{}{}
+		client.Community = community
+		_, err := client.Get([]string{sysUpTime})
+		if err == nil {
+			cmu.Lock()
+			communities[p.Host] = community
+			cmu.Unlock()
+			return nil
+		}
+		failed = append(failed, fmt.Sprintf("attempt %d: %s", len(failed)+1, err))
+	}
+	return errors.Errorf("no community worked for host %s (%s)", p.Host, strings.Join(failed, "; "))
 }
@@ -5,10 +5,14 @@
 package snmputil
 
 import (
+	"bytes"
 	"log"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/soniah/gosnmp"
@@ -115,7 +119,7 @@ func testSysName(client *gosnmp.GoSNMP) error {
 }
 
 func TestV2Profile(t *testing.T) {
-	client, err := newClient(profileV2)
+	client, err := NewClient(profileV2)
 	if err != nil {
 		t.Error(err)
 	}
@@ -126,7 +130,7 @@ func TestV2Profile(t *testing.T) {
 }
 
 func TestV3Profile(t *testing.T) {
-	client, err := newClient(profileV3)
+	client, err := NewClient(profileV3)
 	if err != nil {
 		t.Error(err)
 	}
@@ -135,3 +139,204 @@ func TestV3Profile(t *testing.T) {
 	}
 	client.Conn.Close()
 }
+
+func TestClampRetries(t *testing.T) {
+	tests := []struct {
+		in, out int
+		ok      bool
+	}{
+		{0, 0, true},
+		{DefaultRetries, DefaultRetries, true},
+		{2, 2, true},
+		{-5, 0, false},
+		{50, maxRetries, false},
+	}
+	for _, test := range tests {
+		got, ok := clampRetries(test.in)
+		if got != test.out || ok != test.ok {
+			t.Errorf("retries %d: expected %d/%v, got %d/%v", test.in, test.out, test.ok, got, ok)
+		}
+	}
+}
+
+type testCreds struct{}
+
+func (testCreds) Community(host string) (string, error) {
+	return "secret-" + host, nil
+}
+
+func (testCreds) V3(host string) (V3Creds, error) {
+	return V3Creds{SecLevel: "AuthNoPriv", AuthUser: testUser, AuthProto: "MD5", AuthPass: testPassword}, nil
+}
+
+func TestResolveCredentials(t *testing.T) {
+	var o clientOptions
+	WithCredentials(testCreds{})(&o)
+
+	p := Profile{Host: "router1", Version: "2c"}
+	if err := o.resolveCredentials(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Community != "secret-router1" {
+		t.Errorf("unexpected community: %s", p.Community)
+	}
+
+	p = Profile{Host: "router1", Version: "2c", Community: "inline"}
+	if err := o.resolveCredentials(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Community != "inline" {
+		t.Errorf("inline community was replaced: %s", p.Community)
+	}
+
+	p = Profile{Host: "router1", Version: "3"}
+	if err := o.resolveCredentials(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.AuthUser != testUser || p.SecLevel != "AuthNoPriv" {
+		t.Errorf("v3 credentials not resolved: %+v", p)
+	}
+}
+
+func TestCommunitiesFailed(t *testing.T) {
+	agent := dropAgent(t, 1<<30)
+	defer agent.Close()
+	p := profileV2
+	p.Host, p.Port, p.Timeout, p.Retries = "127.0.0.1", agent.LocalAddr().(*net.UDPAddr).Port, 1, 0
+	p.Communities = []string{p.Community}
+	var tried *gosnmp.GoSNMP
+	client, err := NewClient(p, Configure(func(client *gosnmp.GoSNMP) { tried = client }))
+	if err == nil || client != nil {
+		t.Fatalf("expected no client and an error, got %v, %v", client, err)
+	}
+	if _, err := tried.Conn.Write([]byte{0}); err == nil {
+		t.Error("expected the failed client to be closed")
+	}
+}
+
+func TestLazyConnect(t *testing.T) {
+	p := profileV2
+	p.Community = ""
+	p.Communities = []string{"first", "second"}
+	client, err := NewClient(p, LazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Conn != nil {
+		t.Error("expected client to not be connected")
+	}
+	if client.Community != "first" {
+		t.Errorf("expected first community, got: %s", client.Community)
+	}
+	if err := connected(client); err != nil {
+		t.Fatal(err)
+	}
+	if client.Conn == nil {
+		t.Error("expected client to be connected")
+	}
+	client.Conn.Close()
+}
+
+func TestConfigure(t *testing.T) {
+	var seen gosnmp.SnmpVersion
+	client, err := NewClient(profileV3, LazyConnect(), Configure(func(client *gosnmp.GoSNMP) {
+		seen = client.Version
+		client.ExponentialTimeout = false
+		client.Timeout = 42 * time.Millisecond
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen != gosnmp.Version3 {
+		t.Errorf("expected the hook to see the v3 setup, got version %v", seen)
+	}
+	if client.Timeout != 42*time.Millisecond || client.ExponentialTimeout {
+		t.Errorf("hook changes not kept: %v %t", client.Timeout, client.ExponentialTimeout)
+	}
+}
+
+func TestConfigureTimeoutWarning(t *testing.T) {
+	if snmpLogger != nil {
+		t.Skip("warnings go to the debug logger")
+	}
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+	p := profileV3
+	p.Timeout, p.Retries = 5, 0
+	_, err := NewClient(p, LazyConnect(), Configure(func(client *gosnmp.GoSNMP) {
+		client.Timeout = 100 * time.Millisecond
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(logged.String(), p.Host) {
+		t.Errorf("expected a warning for the configured timeout, got %q", logged.String())
+	}
+}
+
+func TestV3TimeoutWarning(t *testing.T) {
+	for _, test := range []struct {
+		p    Profile
+		warn bool
+	}{
+		{Profile{Timeout: 1, Retries: 0}, true},
+		{Profile{Timeout: 1, Retries: 1}, false},
+		{Profile{Timeout: 2, Retries: 0}, false},
+		{Profile{Timeout: 1, Retries: 0, AuthEngineID: "80001f8880"}, false},
+		{Profile{Timeout: 1, Retries: 0, Version: "2c"}, false},
+	} {
+		p := profileV3
+		p.Timeout, p.Retries, p.AuthEngineID = test.p.Timeout, test.p.Retries, test.p.AuthEngineID
+		if len(test.p.Version) > 0 {
+			p.Version = test.p.Version
+		}
+		client, err := NewClient(p, LazyConnect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if warning := v3TimeoutWarning(client); (len(warning) > 0) != test.warn {
+			t.Errorf("timeout %d retries %d engine %q version %s: unexpected warning %q", p.Timeout, p.Retries, p.AuthEngineID, p.Version, warning)
+		}
+	}
+}
+
+func TestMaxOids(t *testing.T) {
+	if n, err := maxOids(gosnmp.Version1, 0); err != nil || n != defaultOidsV1 {
+		t.Errorf("unexpected v1 default: %d %v", n, err)
+	}
+	if n, err := maxOids(gosnmp.Version2c, 0); err != nil || n != gosnmp.MaxOids {
+		t.Errorf("unexpected v2c default: %d %v", n, err)
+	}
+	if n, err := maxOids(gosnmp.Version2c, 100); err != nil || n != 100 {
+		t.Errorf("unexpected v2c value: %d %v", n, err)
+	}
+	if _, err := maxOids(gosnmp.Version1, 100); err == nil {
+		t.Error("expected error for oversized v1 MaxOids")
+	}
+	if _, err := maxOids(gosnmp.Version3, -1); err == nil {
+		t.Error("expected error for negative MaxOids")
+	}
+}
+
+func TestMaxRepetitions(t *testing.T) {
+	p := profileV2
+	client, err := NewClient(p, LazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.MaxRepetitions != defaultRepetitions {
+		t.Errorf("expected default of %d, got %d", defaultRepetitions, client.MaxRepetitions)
+	}
+	p.MaxRepetitions = 5
+	if client, err = NewClient(p, LazyConnect()); err != nil {
+		t.Fatal(err)
+	}
+	if client.MaxRepetitions != 5 {
+		t.Errorf("expected 5, got %d", client.MaxRepetitions)
+	}
+	p.MaxRepetitions = 1000
+	if _, err := NewClient(p, LazyConnect()); err == nil {
+		t.Error("expected error for oversized MaxRepetitions")
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Diff is the difference between two sets of results
+type Diff struct {
+	Added   map[string]interface{}
+	Changed map[string]interface{}
+	Removed []string
+}
+
+// Empty reports whether there were no differences
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// DiffResults compares two results (e.g., from Walk) keyed by OID
+func DiffResults(prev, cur map[string]interface{}) Diff {
+	d := Diff{
+		Added:   make(map[string]interface{}),
+		Changed: make(map[string]interface{}),
+	}
+	for k, v := range cur {
+		old, ok := prev[k]
+		switch {
+		case !ok:
+			d.Added[k] = v
+		case !reflect.DeepEqual(old, v):
+			d.Changed[k] = v
+		}
+	}
+	for k := range prev {
+		if _, ok := cur[k]; !ok {
+			d.Removed = append(d.Removed, k)
+		}
+	}
+	SortOIDs(d.Removed)
+	return d
+}
+
+type sample struct {
+	name  string
+	tags  map[string]string
+	value interface{}
+	ts    TimeStamp
+}
+
+// deltaSender buffers the values sent during a polling cycle
+// and passes on only those that differ from the prior cycle
+type deltaSender struct {
+	sender  Sender
+	first   bool
+	prev    map[string]interface{}
+	cur     map[string]interface{}
+	samples map[string]sample
+	last    map[string]sample
+}
+
+func newDeltaSender(sender Sender) *deltaSender {
+	return &deltaSender{
+		sender:  sender,
+		first:   true,
+		prev:    make(map[string]interface{}),
+		cur:     make(map[string]interface{}),
+		samples: make(map[string]sample),
+		last:    make(map[string]sample),
+	}
+}
+
+// seriesKey identifies a value by its name and tags
+func seriesKey(name string, tags map[string]string) string {
+	if oid, ok := tags["oid"]; ok {
+		return oid
+	}
+	keys := sortedKeys(tags)
+	parts := make([]string, 0, len(keys)+1)
+	parts = append(parts, name)
+	for _, k := range keys {
+		parts = append(parts, k+"="+tags[k])
+	}
+	return strings.Join(parts, ",")
+}
+
+func (d *deltaSender) send(name string, tags map[string]string, value interface{}, ts TimeStamp) error {
+	key := seriesKey(name, tags)
+	d.cur[key] = value
+	d.samples[key] = sample{name, tags, value, ts}
+	return nil
+}
+
+// flush sends the values that changed during the cycle. The first cycle
+// sends everything as a baseline. Values no longer present are sent with
+// a nil value.
+func (d *deltaSender) flush() error {
+	diff := DiffResults(d.prev, d.cur)
+	keys := make([]string, 0, len(d.samples))
+	for k := range d.samples {
+		if _, ok := diff.Added[k]; ok || d.first {
+			keys = append(keys, k)
+		} else if _, ok := diff.Changed[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var err error
+	save := func(e error) {
+		if err == nil {
+			err = e
+		}
+	}
+	for _, k := range keys {
+		s := d.samples[k]
+		save(d.sender(s.name, s.tags, s.value, s.ts))
+	}
+	for _, k := range diff.Removed {
+		if s, ok := d.last[k]; ok {
+			save(d.sender(s.name, s.tags, nil, s.ts))
+		}
+	}
+
+	d.first = false
+	d.prev, d.cur = d.cur, make(map[string]interface{})
+	d.last, d.samples = d.samples, make(map[string]sample)
+	return err
+}
+
+// discard drops the values of an incomplete cycle, keeping the previous
+// snapshot to compare the next cycle against
+func (d *deltaSender) discard() {
+	d.cur = make(map[string]interface{})
+	d.samples = make(map[string]sample)
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+)
+
+func TestDiffResults(t *testing.T) {
+	prev := map[string]interface{}{".1.1": 1, ".1.2": []byte("a"), ".1.3": 3}
+	cur := map[string]interface{}{".1.1": 1, ".1.2": []byte("b"), ".1.4": 4}
+	d := DiffResults(prev, cur)
+	if len(d.Added) != 1 || d.Added[".1.4"] != 4 {
+		t.Errorf("unexpected added: %v", d.Added)
+	}
+	if len(d.Changed) != 1 || string(d.Changed[".1.2"].([]byte)) != "b" {
+		t.Errorf("unexpected changed: %v", d.Changed)
+	}
+	if len(d.Removed) != 1 || d.Removed[0] != ".1.3" {
+		t.Errorf("unexpected removed: %v", d.Removed)
+	}
+	if !DiffResults(cur, cur).Empty() {
+		t.Error("expected no difference")
+	}
+}
+
+func TestDeltaSender(t *testing.T) {
+	got := map[string]interface{}{}
+	sender := func(name string, tags map[string]string, value interface{}, ts TimeStamp) error {
+		got[name] = value
+		return nil
+	}
+	d := newDeltaSender(sender)
+	cycle := func(values map[string]interface{}) map[string]interface{} {
+		got = map[string]interface{}{}
+		for k, v := range values {
+			d.send(k, map[string]string{"host": testHost}, v, TimeStamp{})
+		}
+		d.flush()
+		return got
+	}
+
+	if sent := cycle(map[string]interface{}{"a": 1, "b": 2}); len(sent) != 2 {
+		t.Errorf("expected baseline to be sent, got: %v", sent)
+	}
+	if sent := cycle(map[string]interface{}{"a": 1, "b": 3}); len(sent) != 1 || sent["b"] != 3 {
+		t.Errorf("expected only changed value, got: %v", sent)
+	}
+	if sent := cycle(map[string]interface{}{"b": 3}); len(sent) != 1 || sent["a"] != nil {
+		t.Errorf("expected removed value, got: %v", sent)
+	}
+
+	// a failed cycle is not compared, so nothing looks removed
+	d.send("c", nil, 5, TimeStamp{})
+	d.discard()
+	if sent := cycle(map[string]interface{}{"b": 3}); len(sent) != 0 {
+		t.Errorf("expected no change after a failed cycle, got: %v", sent)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type dnsEntry struct {
+	addr    string
+	expires time.Time
+}
+
+// DNSCache remembers resolved host addresses for a TTL so that
+// clients repeatedly created for the same host don't each do a lookup
+type DNSCache struct {
+	sync.Mutex
+	ttl     time.Duration
+	entries map[string]dnsEntry
+	lookup  func(network, host string) ([]string, error)
+	now     func() time.Time
+}
+
+// NewDNSCache returns a cache holding addresses for ttl
+func NewDNSCache(ttl time.Duration) *DNSCache {
+	return &DNSCache{
+		ttl:     ttl,
+		entries: make(map[string]dnsEntry),
+		lookup:  lookupAddrs,
+		now:     time.Now,
+	}
+}
+
+// addressFamily returns the network to resolve for a Profile's AddressFamily
+func addressFamily(family string) (string, error) {
+	switch family {
+	case "":
+		return "ip", nil
+	case "ip4", "ip6":
+		return family, nil
+	}
+	return "", errors.Errorf("invalid address family: %s", family)
+}
+
+// lookupAddrs returns the addresses of host in the network's family
+func lookupAddrs(network, host string) ([]string, error) {
+	ips, err := net.DefaultResolver.LookupIP(context.Background(), network, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, ip.String())
+	}
+	return addrs, nil
+}
+
+// literalAddr checks that host, if an IP address, is in the network's family
+func literalAddr(network, host string) (string, bool, error) {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", false, nil
+	}
+	if (network == "ip4" && ip.To4() == nil) || (network == "ip6" && ip.To4() != nil) {
+		return "", true, errors.Errorf("address %s is not in family %s", host, network)
+	}
+	return host, true, nil
+}
+
+// resolveHost returns the first address of host in the network's family
+func resolveHost(network, host string) (string, error) {
+	if addr, ok, err := literalAddr(network, host); ok {
+		return addr, err
+	}
+	addrs, err := lookupAddrs(network, host)
+	if err != nil {
+		return "", errors.Wrapf(err, "resolve %s", host)
+	}
+	if len(addrs) == 0 {
+		return "", errors.Errorf("no %s addresses for %s", network, host)
+	}
+	return addrs[0], nil
+}
+
+// Resolve returns an address for host, looking it up if not cached or expired.
+// IP addresses are returned as is.
+func (c *DNSCache) Resolve(host string) (string, error) {
+	return c.resolve("ip", host)
+}
+
+// resolve returns an address for host in the network's family
+func (c *DNSCache) resolve(network, host string) (string, error) {
+	if addr, ok, err := literalAddr(network, host); ok {
+		return addr, err
+	}
+	key := network + "/" + host
+	c.Lock()
+	now := c.now()
+	e, ok := c.entries[key]
+	c.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.addr, nil
+	}
+	// not locked, so a slow lookup doesn't hold up other hosts
+	addrs, err := c.lookup(network, host)
+	if err != nil {
+		return "", errors.Wrapf(err, "resolve %s", host)
+	}
+	if len(addrs) == 0 {
+		return "", errors.Errorf("no %s addresses for %s", network, host)
+	}
+	c.Lock()
+	c.entries[key] = dnsEntry{addr: addrs[0], expires: now.Add(c.ttl)}
+	c.Unlock()
+	return addrs[0], nil
+}
+
+// Forget removes host from the cache
+func (c *DNSCache) Forget(host string) {
+	c.Lock()
+	for _, network := range []string{"ip", "ip4", "ip6"} {
+		delete(c.entries, network+"/"+host)
+	}
+	c.Unlock()
+}
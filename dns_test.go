@@ -0,0 +1,146 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestDNSCache(t *testing.T) {
+	now := time.Now()
+	lookups := 0
+	addrs := []string{"10.0.0.1"}
+	c := NewDNSCache(time.Minute)
+	c.now = func() time.Time { return now }
+	c.lookup = func(network, host string) ([]string, error) {
+		lookups++
+		return addrs, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		addr, err := c.Resolve("router1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr != "10.0.0.1" {
+			t.Errorf("expected 10.0.0.1, got %s", addr)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("expected 1 lookup, got %d", lookups)
+	}
+
+	addrs = []string{"10.0.0.2"}
+	now = now.Add(2 * time.Minute)
+	if addr, _ := c.Resolve("router1"); addr != "10.0.0.2" {
+		t.Errorf("expected new address after ttl, got %s", addr)
+	}
+	if addr, _ := c.Resolve("10.1.1.1"); addr != "10.1.1.1" {
+		t.Errorf("expected IP to be returned as is, got %s", addr)
+	}
+	if lookups != 2 {
+		t.Errorf("expected 2 lookups, got %d", lookups)
+	}
+}
+
+func TestAddressFamily(t *testing.T) {
+	c := NewDNSCache(time.Minute)
+	c.lookup = func(network, host string) ([]string, error) {
+		if network == "ip6" {
+			return []string{"2001:db8::1"}, nil
+		}
+		return []string{"10.0.0.1"}, nil
+	}
+	if addr, _ := c.resolve("ip6", "router1"); addr != "2001:db8::1" {
+		t.Errorf("expected ip6 address, got %s", addr)
+	}
+	if addr, _ := c.resolve("ip4", "router1"); addr != "10.0.0.1" {
+		t.Errorf("expected ip4 address, got %s", addr)
+	}
+	if _, err := c.resolve("ip6", "10.1.1.1"); err == nil {
+		t.Error("expected error for ip4 literal with ip6 family")
+	}
+	if _, err := addressFamily("ipx"); err == nil {
+		t.Error("expected error for invalid family")
+	}
+
+	client, err := NewClient(Profile{Host: "127.0.0.1", AddressFamily: "ip4"}, LazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Transport != "udp4" {
+		t.Errorf("expected udp4 transport, got %s", client.Transport)
+	}
+	if _, err := NewClient(Profile{Host: "127.0.0.1", AddressFamily: "ip6"}, LazyConnect()); err == nil {
+		t.Error("expected error for ip4 host with ip6 family")
+	}
+}
+
+func TestDNSCacheSlowLookup(t *testing.T) {
+	c := NewDNSCache(time.Minute)
+	slow := make(chan struct{})
+	c.lookup = func(network, host string) ([]string, error) {
+		if host == "slow" {
+			<-slow
+		}
+		return []string{"10.0.0.1"}, nil
+	}
+	go c.Resolve("slow")
+	defer close(slow)
+
+	done := make(chan struct{})
+	go func() {
+		c.Resolve("fast")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("lookup waited on another host's lookup")
+	}
+}
+
+func TestDNSCacheEngine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "engines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache, err := NewFileEngineCache(filepath.Join(dir, "engines.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetEngineCache(cache)
+	defer SetEngineCache(nil)
+
+	dns := NewDNSCache(time.Minute)
+	dns.lookup = func(network, host string) ([]string, error) {
+		return []string{"127.0.0.1"}, nil
+	}
+	p := profileV3
+	p.Host = "router1.example"
+	client, err := NewClient(p, WithDNSCache(dns), LazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// as saved after the client discovers its engine
+	usm := client.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	usm.AuthoritativeEngineID = "\x80\x00\x1f\x88\x80"
+	checkEngine(client)
+
+	again, err := NewClient(p, WithDNSCache(dns), LazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := again.SecurityParameters.(*gosnmp.UsmSecurityParameters).AuthoritativeEngineID; id != usm.AuthoritativeEngineID {
+		t.Errorf("expected the cached engine for the resolved host, got %x", id)
+	}
+}
@@ -0,0 +1,392 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// engine IDs are 5 to 32 octets (RFC 3411)
+const (
+	minEngineID = 5
+	maxEngineID = 32
+)
+
+// ParseEngineID decodes an engine ID given as bare hex ("80001f88..."),
+// 0x prefixed hex, or colon separated octets ("80:00:1f:88:...")
+func ParseEngineID(s string) ([]byte, error) {
+	h := strings.TrimSpace(s)
+	if strings.HasPrefix(h, "0x") || strings.HasPrefix(h, "0X") {
+		h = h[2:]
+	}
+	if strings.Contains(h, ":") {
+		octets := strings.Split(h, ":")
+		for i, o := range octets {
+			if len(o) == 0 || len(o) > 2 {
+				return nil, errors.Errorf("invalid octet %q in engine ID %q", o, s)
+			}
+			if len(o) == 1 {
+				octets[i] = "0" + o
+			}
+		}
+		h = strings.Join(octets, "")
+	}
+	if len(h)%2 != 0 {
+		return nil, errors.Errorf("engine ID %q has an odd number of hex digits", s)
+	}
+	id, err := hex.DecodeString(h)
+	if err != nil {
+		return nil, errors.Errorf("engine ID %q is not valid hex", s)
+	}
+	if len(id) < minEngineID || len(id) > maxEngineID {
+		return nil, errors.Errorf("engine ID %q is %d octets, must be %d to %d", s, len(id), minEngineID, maxEngineID)
+	}
+	return id, nil
+}
+
+var (
+	// engineCache, if set, is consulted by NewClient to skip v3 engine discovery
+	engineCache EngineCache
+	ecmu        sync.Mutex
+)
+
+// EngineInfo is the result of SNMPv3 engine discovery and key localization for a host
+type EngineInfo struct {
+	EngineID string // hex encoded
+	Boots    uint32
+	Time     uint32
+	User     string
+	AuthKey  []byte
+	PrivKey  []byte
+	Creds    string // hash of the credentials the keys were localized from
+	Saved    time.Time
+}
+
+// EngineCache stores discovered SNMPv3 engine info by host
+type EngineCache interface {
+	Get(host string) (EngineInfo, bool)
+	Put(host string, info EngineInfo) error
+	Delete(host string) error
+}
+
+// FileEngineCache is an EngineCache persisted as a JSON file
+type FileEngineCache struct {
+	sync.Mutex
+	filename string
+	engines  map[string]EngineInfo
+}
+
+// NewFileEngineCache returns an EngineCache backed by filename,
+// loading any entries previously saved
+func NewFileEngineCache(filename string) (*FileEngineCache, error) {
+	c := &FileEngineCache{filename: filename, engines: make(map[string]EngineInfo)}
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &c.engines); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Get returns the engine info saved for host
+func (c *FileEngineCache) Get(host string) (EngineInfo, bool) {
+	c.Lock()
+	defer c.Unlock()
+	info, ok := c.engines[host]
+	return info, ok
+}
+
+// Put saves the engine info for host
+func (c *FileEngineCache) Put(host string, info EngineInfo) error {
+	c.Lock()
+	defer c.Unlock()
+	c.engines[host] = info
+	return c.save()
+}
+
+// Delete removes the engine info for host
+func (c *FileEngineCache) Delete(host string) error {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.engines, host)
+	return c.save()
+}
+
+// save writes the cache to disk, must be called with the lock held
+func (c *FileEngineCache) save() error {
+	b, err := json.MarshalIndent(c.engines, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.filename)
+}
+
+// SetEngineCache sets the cache used by NewClient for SNMPv3 engine data
+func SetEngineCache(cache EngineCache) {
+	ecmu.Lock()
+	engineCache = cache
+	ecmu.Unlock()
+}
+
+func currentEngineCache() EngineCache {
+	ecmu.Lock()
+	defer ecmu.Unlock()
+	return engineCache
+}
+
+// credentialHash identifies the credentials keys are localized from,
+// so that keys cached for old credentials are not reused
+func credentialHash(usm *gosnmp.UsmSecurityParameters) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00%s\x00%d\x00%s", usm.UserName,
+		usm.AuthenticationProtocol, usm.AuthenticationPassphrase,
+		usm.PrivacyProtocol, usm.PrivacyPassphrase)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedEngine applies saved engine data for the host, if any, returning
+// when it was saved. The cached keys are only used if they were localized
+// from the same credentials, otherwise gosnmp localizes them anew.
+func cachedEngine(host string, usm *gosnmp.UsmSecurityParameters) (time.Time, bool) {
+	cache := currentEngineCache()
+	if cache == nil || len(usm.AuthoritativeEngineID) > 0 {
+		return time.Time{}, false
+	}
+	info, ok := cache.Get(host)
+	if !ok || info.User != usm.UserName {
+		return time.Time{}, false
+	}
+	id, err := hex.DecodeString(info.EngineID)
+	if err != nil {
+		cache.Delete(host)
+		return time.Time{}, false
+	}
+	usm.AuthoritativeEngineID = string(id)
+	usm.AuthoritativeEngineBoots = info.Boots
+	usm.AuthoritativeEngineTime = info.Time
+	if info.Creds == credentialHash(usm) {
+		// the cached keys are shared by every client of the host
+		usm.SecretKey = copyBytes(info.AuthKey)
+		usm.PrivacyKey = copyBytes(info.PrivKey)
+	}
+	return info.Saved, true
+}
+
+// rediscovering reports if a v3 client was reset by checkEngine to
+// rediscover its engine
+func rediscovering(client *gosnmp.GoSNMP) bool {
+	usm, ok := client.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	return ok && client.Version == gosnmp.Version3 && len(usm.AuthoritativeEngineID) == 0
+}
+
+// CloneSecurityParameters returns a copy of sp sharing no mutable state,
+// so that it can be given to another client. gosnmp updates the engine
+// boots, time and keys of a client's security parameters in place, and
+// its own Copy shares the localized key buffers.
+func CloneSecurityParameters(sp gosnmp.SnmpV3SecurityParameters) gosnmp.SnmpV3SecurityParameters {
+	if sp == nil {
+		return nil
+	}
+	clone := sp.Copy()
+	if usm, ok := clone.(*gosnmp.UsmSecurityParameters); ok {
+		usm.SecretKey = copyBytes(usm.SecretKey)
+		usm.PrivacyKey = copyBytes(usm.PrivacyKey)
+	}
+	return clone
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+// checkEngine reconciles the engine cache with the client's state after a
+// request, and is called for every request made by the package helpers.
+// Newly discovered engines are saved; if the agent reports a different
+// engine than was cached the entry is invalidated, the client is reset to
+// rediscover the engine on its next request and true is returned.
+func checkEngine(client *gosnmp.GoSNMP) bool {
+	if client.Version != gosnmp.Version3 {
+		return false
+	}
+	cache := currentEngineCache()
+	if cache == nil {
+		return false
+	}
+	usm, ok := client.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	if !ok || len(usm.AuthoritativeEngineID) == 0 {
+		return false
+	}
+	id := hex.EncodeToString([]byte(usm.AuthoritativeEngineID))
+	creds := credentialHash(usm)
+	info, ok := cache.Get(client.Target)
+	if ok && info.User == usm.UserName && info.EngineID != id {
+		cache.Delete(client.Target)
+		// the keys were localized to the cached engine
+		usm.AuthoritativeEngineID = ""
+		usm.SecretKey = nil
+		usm.PrivacyKey = nil
+		return true
+	}
+	if !ok || info.User != usm.UserName || info.Creds != creds {
+		cache.Put(client.Target, EngineInfo{
+			EngineID: id,
+			Boots:    usm.AuthoritativeEngineBoots,
+			Time:     usm.AuthoritativeEngineTime,
+			User:     usm.UserName,
+			AuthKey:  usm.SecretKey,
+			PrivKey:  usm.PrivacyKey,
+			Creds:    creds,
+			Saved:    time.Now(),
+		})
+	}
+	return false
+}
+
+const snmpEngineID = ".1.3.6.1.6.3.10.2.1.1.0"
+
+// DeviceEngineID returns the agent's engine ID, which identifies the device
+// regardless of the address it was reached by. For v3 it is the engine
+// discovered by the client, otherwise it is read from snmpEngineID.
+func DeviceEngineID(client *gosnmp.GoSNMP) ([]byte, error) {
+	if err := connected(client); err != nil {
+		return nil, err
+	}
+	if usm, ok := client.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok && client.Version == gosnmp.Version3 {
+		if len(usm.AuthoritativeEngineID) == 0 {
+			// any request triggers discovery
+			packet, err := client.Get([]string{sysUpTime})
+			if err == nil {
+				err = packetError(client, packet)
+			}
+			recordStats(client, err)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(usm.AuthoritativeEngineID) == 0 {
+			return nil, errors.New("no engine ID discovered")
+		}
+		return []byte(usm.AuthoritativeEngineID), nil
+	}
+	packet, err := client.Get([]string{snmpEngineID})
+	recordStats(client, err)
+	if err != nil {
+		return nil, err
+	}
+	if len(packet.Variables) != 1 || packet.Variables[0].Type != gosnmp.OctetString {
+		return nil, ErrNoSuchObject
+	}
+	id, ok := packet.Variables[0].Value.([]byte)
+	if !ok || len(id) == 0 {
+		return nil, ErrNoSuchObject
+	}
+	return id, nil
+}
+
+// engineClockKey is the key of a client's engineClock in its Context
+type engineClockKey struct{}
+
+// engineClock is the local time a client's engine booted, by its engine
+// time when first known
+type engineClock struct {
+	sync.Mutex
+	known  bool
+	boots  uint32
+	booted time.Time
+}
+
+// clockOf returns the client's engine clock, adding one if need be
+func clockOf(client *gosnmp.GoSNMP) *engineClock {
+	if client.Context != nil {
+		if c, ok := client.Context.Value(engineClockKey{}).(*engineClock); ok {
+			return c
+		}
+	}
+	c := &engineClock{}
+	setClientValue(client, engineClockKey{}, c)
+	return c
+}
+
+// note records the engine time at the given local time as the baseline,
+// unless there is one for the same engine boot, and returns the skew
+// since the baseline
+func (c *engineClock) note(boots, engineTime uint32, at time.Time) time.Duration {
+	booted := at.Add(-time.Duration(engineTime) * time.Second)
+	c.Lock()
+	defer c.Unlock()
+	if !c.known || c.boots != boots {
+		c.known, c.boots, c.booted = true, boots, booted
+		return 0
+	}
+	return c.booted.Sub(booted)
+}
+
+// noteEngineClock sets the baseline of an SNMPv3 client's engine clock
+// once its engine is known
+func noteEngineClock(client *gosnmp.GoSNMP) {
+	if client.Version != gosnmp.Version3 {
+		return
+	}
+	if usm, ok := client.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok && len(usm.AuthoritativeEngineID) > 0 {
+		clockOf(client).note(usm.AuthoritativeEngineBoots, usm.AuthoritativeEngineTime, time.Now())
+	}
+}
+
+// EngineTimeSkew returns how far the agent's SNMPv3 engine clock has run
+// ahead of the local clock (negative if behind) since the engine was
+// discovered by the client, or since it was saved to the engine cache
+// (see SetEngineCache). It makes a request to learn the agent's current
+// engine time; a skew growing toward the 150 second time window explains
+// usmStatsNotInTimeWindows failures. A client that discovers its engine
+// with this request has no earlier baseline and returns 0. The
+// measurement restarts if the engine reboots, and has a resolution of a
+// second.
+func EngineTimeSkew(client *gosnmp.GoSNMP) (time.Duration, error) {
+	usm, ok := client.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	if !ok || client.Version != gosnmp.Version3 {
+		return 0, errors.New("engine time is only known for SNMPv3")
+	}
+	if err := connected(client); err != nil {
+		return 0, err
+	}
+	// the response carries the engine's current boots and time
+	packet, err := client.Get([]string{sysUpTime})
+	if err == nil {
+		err = packetError(client, packet)
+	}
+	recordStats(client, err)
+	if err != nil {
+		return 0, err
+	}
+	if len(usm.AuthoritativeEngineID) == 0 {
+		return 0, errors.New("no engine ID discovered")
+	}
+	return clockOf(client).note(usm.AuthoritativeEngineBoots, usm.AuthoritativeEngineTime, time.Now()), nil
+}
@@ -0,0 +1,230 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestFileEngineCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "engines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "engines.json")
+
+	cache, err := NewFileEngineCache(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := EngineInfo{EngineID: "80001f8880", Boots: 3, User: testUser, AuthKey: []byte{1, 2, 3}}
+	if err := cache.Put(testHost, info); err != nil {
+		t.Fatal(err)
+	}
+
+	reload, err := NewFileEngineCache(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := reload.Get(testHost)
+	if !ok {
+		t.Fatal("engine not found after reload")
+	}
+	if got.EngineID != info.EngineID || got.Boots != info.Boots || string(got.AuthKey) != string(info.AuthKey) {
+		t.Errorf("expected %+v, got %+v", info, got)
+	}
+	if err := reload.Delete(testHost); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reload.Get(testHost); ok {
+		t.Error("engine found after delete")
+	}
+}
+
+func TestParseEngineID(t *testing.T) {
+	expect := []byte{0x80, 0x00, 0x1f, 0x88, 0x80}
+	for _, s := range []string{"80001f8880", "0x80001F8880", "80:00:1f:88:80", "80:0:1f:88:80", " 80001f8880 "} {
+		id, err := ParseEngineID(s)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", s, err)
+			continue
+		}
+		if string(id) != string(expect) {
+			t.Errorf("expected %x for %q, got %x", expect, s, id)
+		}
+	}
+	for _, s := range []string{"", "80001f888", "80001f88zz", "80::1f:88:80", "8000", "80:001:1f:88:80"} {
+		if _, err := ParseEngineID(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestDeviceEngineID(t *testing.T) {
+	id := []byte{0x80, 0x00, 0x1f, 0x88, 0x80, 0x01}
+	client := replayAgent(t, Exchange{
+		PDUType:  gosnmp.GetRequest,
+		OIDs:     []string{snmpEngineID},
+		Response: []Record{{OID: snmpEngineID, Type: int32(gosnmp.OctetString), BytesVal: id}},
+	})
+
+	got, err := DeviceEngineID(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(id) {
+		t.Errorf("expected %x, got %x", id, got)
+	}
+}
+
+func TestCloneSecurityParameters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "engines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache, err := NewFileEngineCache(filepath.Join(dir, "engines.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	usm := func(client *gosnmp.GoSNMP) *gosnmp.UsmSecurityParameters {
+		return client.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	}
+	uncached, err := NewClient(profileV3, LazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := EngineInfo{EngineID: "80001f8880", Boots: 3, Time: 100, User: testUser, AuthKey: []byte{1, 2, 3}, Creds: credentialHash(usm(uncached))}
+	if err := cache.Put(testHost, info); err != nil {
+		t.Fatal(err)
+	}
+	SetEngineCache(cache)
+	defer SetEngineCache(nil)
+
+	first, err := NewClient(profileV3, LazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewClient(profileV3, LazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := usm(first), usm(second)
+	if a == b {
+		t.Fatal("clients share security parameters")
+	}
+	// as gosnmp does on receiving a report or rediscovering the engine
+	a.AuthoritativeEngineBoots++
+	a.AuthoritativeEngineTime = 200
+	a.SecretKey[0] = 9
+	if b.AuthoritativeEngineBoots != info.Boots || b.AuthoritativeEngineTime != info.Time || b.SecretKey[0] != 1 {
+		t.Errorf("second client was changed by the first: %+v", b)
+	}
+
+	clone := CloneSecurityParameters(a).(*gosnmp.UsmSecurityParameters)
+	if clone == a || clone.UserName != a.UserName || clone.AuthoritativeEngineBoots != a.AuthoritativeEngineBoots {
+		t.Fatalf("bad clone: %+v", clone)
+	}
+	clone.SecretKey[0] = 7
+	if a.SecretKey[0] != 9 {
+		t.Error("clone shares the key buffer")
+	}
+	if CloneSecurityParameters(nil) != nil {
+		t.Error("expected nil clone of nil")
+	}
+}
+
+func TestCachedEngineCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "engines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache, err := NewFileEngineCache(filepath.Join(dir, "engines.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	usm := &gosnmp.UsmSecurityParameters{UserName: testUser, AuthenticationProtocol: gosnmp.MD5, AuthenticationPassphrase: "old secret"}
+	cache.engines[testHost] = EngineInfo{EngineID: "80001f8880", User: testUser, AuthKey: []byte{1, 2, 3}, Creds: credentialHash(usm)}
+	SetEngineCache(cache)
+	defer SetEngineCache(nil)
+
+	// the passphrase changed, so only the engine is reused
+	usm.AuthenticationPassphrase = "new secret"
+	if _, ok := cachedEngine(testHost, usm); !ok {
+		t.Fatal("expected the cached engine to be used")
+	}
+	if usm.AuthoritativeEngineID != "\x80\x00\x1f\x88\x80" || usm.SecretKey != nil {
+		t.Errorf("expected the engine without its keys, got %x with key %x", usm.AuthoritativeEngineID, usm.SecretKey)
+	}
+
+	// keys localized anew are saved for the new credentials
+	usm.SecretKey = []byte{4, 5, 6}
+	client := &gosnmp.GoSNMP{Target: testHost, Version: gosnmp.Version3, SecurityParameters: usm}
+	if checkEngine(client) {
+		t.Fatal("expected the engine to be current")
+	}
+	if info := cache.engines[testHost]; info.Creds != credentialHash(usm) || info.AuthKey[0] != 4 {
+		t.Errorf("expected the new keys to be cached, got %+v", info)
+	}
+}
+
+func TestEngineSkew(t *testing.T) {
+	client := &gosnmp.GoSNMP{}
+	clock := clockOf(client)
+	start := time.Unix(1476451815, 0)
+	if skew := clock.note(3, 1000, start); skew != 0 {
+		t.Errorf("expected no skew on the first note, got %s", skew)
+	}
+	if clockOf(client) != clock {
+		t.Fatal("expected the clock to be kept by the client")
+	}
+	// the engine counted 110 seconds while 100 passed locally
+	if skew := clock.note(3, 1110, start.Add(100*time.Second)); skew != 10*time.Second {
+		t.Errorf("expected 10s ahead, got %s", skew)
+	}
+	if skew := clock.note(3, 1190, start.Add(200*time.Second)); skew != -10*time.Second {
+		t.Errorf("expected 10s behind, got %s", skew)
+	}
+	// a reboot starts over
+	if skew := clock.note(4, 5, start.Add(300*time.Second)); skew != 0 {
+		t.Errorf("expected no skew after a reboot, got %s", skew)
+	}
+
+	// a cached engine is the baseline for a new client
+	dir, err := ioutil.TempDir("", "engines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache, err := NewFileEngineCache(filepath.Join(dir, "engines.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := time.Now().Add(-100 * time.Second)
+	if err := cache.Put(testHost, EngineInfo{EngineID: "80001f8880", Boots: 3, Time: 1000, User: testUser, Saved: saved}); err != nil {
+		t.Fatal(err)
+	}
+	SetEngineCache(cache)
+	defer SetEngineCache(nil)
+	fresh, err := NewClient(profileV3, LazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skew := clockOf(fresh).note(3, 1110, saved.Add(100*time.Second)); skew != 10*time.Second {
+		t.Errorf("expected 10s ahead of the cached engine, got %s", skew)
+	}
+
+	if _, err := EngineTimeSkew(&gosnmp.GoSNMP{Version: gosnmp.Version2c}); err == nil {
+		t.Error("expected error for a v2c client")
+	}
+}
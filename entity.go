@@ -0,0 +1,124 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+const entPhysicalTable = ".1.3.6.1.2.1.47.1.1.1"
+
+// entPhysicalEntry columns
+const (
+	entPhysicalDescr       = 2
+	entPhysicalContainedIn = 4
+	entPhysicalClass       = 5
+	entPhysicalFirmwareRev = 9
+	entPhysicalSerialNum   = 11
+	entPhysicalModelName   = 13
+)
+
+// PhysicalClass is the general type of a physical entity
+type PhysicalClass int
+
+// Physical classes as defined by ENTITY-MIB
+const (
+	ClassOther PhysicalClass = iota + 1
+	ClassUnknown
+	ClassChassis
+	ClassBackplane
+	ClassContainer
+	ClassPowerSupply
+	ClassFan
+	ClassSensor
+	ClassModule
+	ClassPort
+	ClassStack
+	ClassCPU
+)
+
+var physicalClasses = map[PhysicalClass]string{
+	ClassOther:       "other",
+	ClassUnknown:     "unknown",
+	ClassChassis:     "chassis",
+	ClassBackplane:   "backplane",
+	ClassContainer:   "container",
+	ClassPowerSupply: "powerSupply",
+	ClassFan:         "fan",
+	ClassSensor:      "sensor",
+	ClassModule:      "module",
+	ClassPort:        "port",
+	ClassStack:       "stack",
+	ClassCPU:         "cpu",
+}
+
+func (c PhysicalClass) String() string {
+	if name, ok := physicalClasses[c]; ok {
+		return name
+	}
+	return strconv.Itoa(int(c))
+}
+
+// PhysicalEntity is a row of the entPhysicalTable.
+// Columns the device does not populate are left as zero values.
+type PhysicalEntity struct {
+	Index       int
+	Descr       string
+	Class       PhysicalClass
+	ContainedIn int
+	ModelName   string
+	SerialNum   string
+	FirmwareRev string
+}
+
+// rawString decodes octet strings as is, so that serial numbers
+// and the like are not mistaken for numbers
+func rawString(pdu gosnmp.SnmpPDU) (interface{}, error) {
+	if pdu.Type == gosnmp.OctetString {
+		if b, ok := pdu.Value.([]byte); ok {
+			return cleanString(b), nil
+		}
+	}
+	return pduType(pdu)
+}
+
+// PhysicalInventory returns the device's physical entities ordered by index
+func PhysicalInventory(client *gosnmp.GoSNMP) ([]PhysicalEntity, error) {
+	rows, err := getTable(client, entPhysicalTable, rawString)
+	if err != nil {
+		return nil, err
+	}
+	return physicalEntities(rows)
+}
+
+func physicalEntities(rows map[string]map[int]interface{}) ([]PhysicalEntity, error) {
+	entities := make([]PhysicalEntity, 0, len(rows))
+	for index, row := range rows {
+		i, err := strconv.Atoi(index)
+		if err != nil {
+			return nil, errors.Errorf("invalid entPhysicalIndex: %s", index)
+		}
+		e := PhysicalEntity{Index: i}
+		e.Descr, _ = row[entPhysicalDescr].(string)
+		e.ModelName, _ = row[entPhysicalModelName].(string)
+		e.SerialNum, _ = row[entPhysicalSerialNum].(string)
+		e.FirmwareRev, _ = row[entPhysicalFirmwareRev].(string)
+		if v, ok := row[entPhysicalClass].(int); ok {
+			e.Class = PhysicalClass(v)
+		}
+		if v, ok := row[entPhysicalContainedIn].(int); ok {
+			e.ContainedIn = v
+		}
+		entities = append(entities, e)
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].Index < entities[j].Index
+	})
+	return entities, nil
+}
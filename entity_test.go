@@ -0,0 +1,52 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestRawString(t *testing.T) {
+	pdu := gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("00123")}
+	if v, err := rawString(pdu); err != nil || v != "00123" {
+		t.Errorf("expected serial to remain a string, got %v (%T) %v", v, v, err)
+	}
+}
+
+func TestPhysicalEntities(t *testing.T) {
+	rows := map[string]map[int]interface{}{
+		"2": {
+			entPhysicalDescr:       "Fan Tray",
+			entPhysicalClass:       7,
+			entPhysicalContainedIn: 1,
+		},
+		"1": {
+			entPhysicalDescr:     "Chassis",
+			entPhysicalClass:     3,
+			entPhysicalSerialNum: "00123",
+			entPhysicalModelName: "X100",
+		},
+	}
+	expect := []PhysicalEntity{
+		{Index: 1, Descr: "Chassis", Class: ClassChassis, SerialNum: "00123", ModelName: "X100"},
+		{Index: 2, Descr: "Fan Tray", Class: ClassFan, ContainedIn: 1},
+	}
+	got, err := physicalEntities(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %+v, got %+v", expect, got)
+	}
+	if got[1].Class.String() != "fan" {
+		t.Errorf("expected fan, got %s", got[1].Class)
+	}
+	if _, err := physicalEntities(map[string]map[int]interface{}{"1.2": {}}); err == nil {
+		t.Error("expected error for invalid index")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/soniah/gosnmp"
+)
+
+// EnumValue is the value of an enumerated INTEGER and its label from the
+// MIB, e.g., {1, "up"} for ifOperStatus. The label is empty if the value
+// is not one the MIB enumerates.
+type EnumValue struct {
+	Int   int
+	Label string
+}
+
+func (e EnumValue) String() string {
+	if len(e.Label) == 0 {
+		return strconv.Itoa(e.Int)
+	}
+	return e.Label + "(" + strconv.Itoa(e.Int) + ")"
+}
+
+// EnumLabels makes WalkNamed return the values of columns the loaded
+// MIBs declare as enumerations as EnumValues rather than plain ints
+func EnumLabels() WalkOption {
+	return func(o *walkOptions) {
+		o.enums = true
+	}
+}
+
+// mibObject returns the loaded MIB object oid is an instance of, and its OID
+func mibObject(oid string) (string, oidInfo, bool) {
+	for base := oid; len(base) > 0; {
+		if info, ok := oidBase[base]; ok {
+			return base, info, true
+		}
+		i := strings.LastIndex(base, ".")
+		if i < 0 {
+			break
+		}
+		base = base[:i]
+	}
+	return "", oidInfo{}, false
+}
+
+// WalkNamed is Walk, keying the values by the names of the MIB objects
+// they are instances of (e.g., "ifOperStatus.3") where the MIBs are
+// loaded (see LoadMIBs), and by OID otherwise. The values of loaded
+// objects are decoded by their syntax (e.g., a DateAndTime as a
+// time.Time, BITS as the names of the bits set), falling back to the
+// value as Walk returns it where that fails. Enumerated values are
+// plain ints, or EnumValues with EnumLabels.
+func WalkNamed(client *gosnmp.GoSNMP, rootOID string, opts ...WalkOption) (map[string]interface{}, error) {
+	o := newWalkOptions(opts)
+	oid, err := getOID(rootOID)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	fn := func(pdu gosnmp.SnmpPDU) error {
+		value, err := pduType(pdu)
+		if err != nil {
+			return err
+		}
+		base, info, ok := mibObject(pdu.Name)
+		if !ok {
+			values[pdu.Name] = value
+			return nil
+		}
+		if n, isInt := value.(int); isInt && info.Enums != nil {
+			if o.enums {
+				value = EnumValue{Int: n, Label: info.Enums[n]}
+			}
+		} else if info.Fn != nil {
+			if decoded, err := info.Fn(pdu); err == nil {
+				value = decoded
+			}
+		}
+		values[info.String()+pdu.Name[len(base):]] = value
+		return nil
+	}
+	return values, walkFunc(client, opts...)(oid, fn)
+}
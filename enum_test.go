@@ -0,0 +1,80 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestWalkNamed(t *testing.T) {
+	oidReader(MibInfo{Name: "IF-MIB::ifOperStatus", OID: ifOperStatus, Syntax: "INTEGER {up(1), down(2), testing(3)}"})
+	const (
+		root    = ".1.3.6.1.2.1.2.2.1"
+		changed = root + ".30" // stand-ins for DateAndTime and BITS columns
+		flags   = root + ".31"
+	)
+	oidReader(MibInfo{Name: "TEST-MIB::ifChanged", OID: changed, Syntax: "DateAndTime"})
+	oidReader(MibInfo{Name: "TEST-MIB::ifFlags", OID: flags, Syntax: "BITS {up(0), running(1), promisc(2)}"})
+	defer func() {
+		for _, oid := range []string{ifOperStatus, changed, flags} {
+			delete(oidBase, oid)
+		}
+		for _, name := range []string{"ifOperStatus", "ifChanged", "ifFlags"} {
+			delete(lookupOID, name)
+		}
+	}()
+
+	date := []byte{0x07, 0xe0, 10, 14, 13, 30, 15, 0}
+	client := replayAgent(t, Exchange{
+		PDUType:        gosnmp.GetBulkRequest,
+		OIDs:           []string{root},
+		MaxRepetitions: defaultRepetitions,
+		Response: []Record{
+			{OID: ifOperStatus + ".1", Type: int32(gosnmp.Integer), IntVal: 1},
+			{OID: ifOperStatus + ".2", Type: int32(gosnmp.Integer), IntVal: 7},
+			{OID: root + ".9.1", Type: int32(gosnmp.TimeTicks), IntVal: 100},
+			{OID: changed + ".1", Type: int32(gosnmp.OctetString), BytesVal: date},
+			{OID: changed + ".2", Type: int32(gosnmp.OctetString), BytesVal: make([]byte, 8)},
+			{OID: flags + ".1", Type: int32(gosnmp.OctetString), BytesVal: []byte{0xa0}},
+		},
+	})
+
+	values, err := WalkNamed(client, root, EnumLabels())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := values["ifOperStatus.1"]; v != (EnumValue{1, "up"}) {
+		t.Errorf("expected up(1), got %v", v)
+	}
+	if v := values["ifOperStatus.2"]; v != (EnumValue{Int: 7}) {
+		t.Errorf("expected unlabeled 7, got %v", v)
+	}
+	if _, ok := values[root+".9.1"]; !ok {
+		t.Errorf("expected unknown column by OID, got %v", values)
+	}
+	if got, ok := values["ifChanged.1"].(time.Time); !ok || !got.Equal(time.Date(2016, 10, 14, 13, 30, 15, 0, time.UTC)) {
+		t.Errorf("expected a decoded date, got %v", values["ifChanged.1"])
+	}
+	if _, ok := values["ifChanged.2"].(time.Time); ok {
+		t.Errorf("expected an unset date to not be decoded, got %v", values["ifChanged.2"])
+	}
+	if got := values["ifFlags.1"]; got != "up,promisc" {
+		t.Errorf("expected the bits set, got %v", got)
+	}
+
+	values, err = WalkNamed(client, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := values["ifOperStatus.1"]; v != 1 {
+		t.Errorf("expected a plain int without EnumLabels, got %v", v)
+	}
+	if s := (EnumValue{2, "down"}).String(); s != "down(2)" {
+		t.Errorf("unexpected format: %s", s)
+	}
+}
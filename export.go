@@ -0,0 +1,60 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// sortedOIDs returns the OIDs of values in numeric order
+func sortedOIDs(values map[string]interface{}) []string {
+	oids := make([]string, 0, len(values))
+	for oid := range values {
+		oids = append(oids, oid)
+	}
+	SortOIDs(oids)
+	return oids
+}
+
+// exportValue renders a value as text
+func exportValue(value interface{}) string {
+	if b, ok := value.([]byte); ok {
+		return cleanString(b)
+	}
+	return fmt.Sprint(value)
+}
+
+// WriteCSV writes values (e.g., from Walk) as oid,value rows in OID order
+func WriteCSV(w io.Writer, values map[string]interface{}) error {
+	cw := csv.NewWriter(w)
+	for _, oid := range sortedOIDs(values) {
+		if err := cw.Write([]string{oid, exportValue(values[oid])}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes values (e.g., from Walk) as a JSON array of
+// {"oid":...,"value":...} objects in OID order
+func WriteJSON(w io.Writer, values map[string]interface{}) error {
+	type entry struct {
+		OID   string      `json:"oid"`
+		Value interface{} `json:"value"`
+	}
+	entries := make([]entry, 0, len(values))
+	for _, oid := range sortedOIDs(values) {
+		value := values[oid]
+		if b, ok := value.([]byte); ok {
+			value = cleanString(b)
+		}
+		entries = append(entries, entry{oid, value})
+	}
+	return json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,38 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"bytes"
+	"testing"
+)
+
+var exportValues = map[string]interface{}{
+	".1.3.6.1.2.1.10": "ten",
+	".1.3.6.1.2.1.2":  2,
+	".1.3.6.1.2.1.1":  []byte("one"),
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteCSV(&b, exportValues); err != nil {
+		t.Fatal(err)
+	}
+	expected := ".1.3.6.1.2.1.1,one\n.1.3.6.1.2.1.2,2\n.1.3.6.1.2.1.10,ten\n"
+	if b.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteJSON(&b, exportValues); err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"oid":".1.3.6.1.2.1.1","value":"one"},{"oid":".1.3.6.1.2.1.2","value":2},{"oid":".1.3.6.1.2.1.10","value":"ten"}]` + "\n"
+	if b.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
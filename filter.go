@@ -0,0 +1,121 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrOIDDenied is returned for requests touching a subtree an OIDFilter denies
+var ErrOIDDenied = errors.New("oid denied by filter")
+
+// OIDFilter restricts requests to the allowed subtrees, less the denied
+// ones. An empty allow list permits everything not denied.
+type OIDFilter struct {
+	allow, deny []string
+}
+
+// NewOIDFilter returns a filter for the subtrees, given by name or OID
+func NewOIDFilter(allow, deny []string) (*OIDFilter, error) {
+	f := &OIDFilter{}
+	var err error
+	if f.allow, err = filterOIDs(allow); err != nil {
+		return nil, err
+	}
+	if f.deny, err = filterOIDs(deny); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func filterOIDs(names []string) ([]string, error) {
+	oids := make([]string, 0, len(names))
+	for _, name := range names {
+		oid, err := getOID(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "filter subtree %s", name)
+		}
+		oids = append(oids, strings.TrimSuffix(oid, "."))
+	}
+	return oids, nil
+}
+
+// inSubtree returns whether oid is root or below it
+func inSubtree(root, oid string) bool {
+	return oid == root || strings.HasPrefix(oid, root+".")
+}
+
+func inAny(roots []string, oid string) bool {
+	for _, root := range roots {
+		if inSubtree(root, oid) {
+			return true
+		}
+	}
+	return false
+}
+
+// Permits returns whether the filter allows the OID
+func (f *OIDFilter) Permits(oid string) bool {
+	if f == nil {
+		return true
+	}
+	if inAny(f.deny, oid) {
+		return false
+	}
+	return len(f.allow) == 0 || inAny(f.allow, oid)
+}
+
+// checkGet returns ErrOIDDenied if any of the OIDs are not permitted
+func (f *OIDFilter) checkGet(oids []string) error {
+	for _, name := range oids {
+		oid, err := getOID(name)
+		if err != nil {
+			return err
+		}
+		if !f.Permits(oid) {
+			return errors.Wrap(ErrOIDDenied, oid)
+		}
+	}
+	return nil
+}
+
+// walkRoots returns the subtrees to walk in place of root, pruned to
+// those allowed. ErrOIDDenied is returned if nothing under root is
+// allowed, or if walking what is would touch a denied subtree, so that
+// denied values are never requested.
+func (f *OIDFilter) walkRoots(root string) ([]string, error) {
+	oid, err := getOID(root)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return []string{oid}, nil
+	}
+	if inAny(f.deny, oid) {
+		return nil, errors.Wrap(ErrOIDDenied, oid)
+	}
+	roots := []string{oid}
+	if len(f.allow) > 0 && !inAny(f.allow, oid) {
+		roots = roots[:0]
+		for _, allowed := range f.allow {
+			if inSubtree(oid, allowed) {
+				roots = append(roots, allowed)
+			}
+		}
+		if len(roots) == 0 {
+			return nil, errors.Wrap(ErrOIDDenied, oid)
+		}
+	}
+	for _, r := range roots {
+		for _, denied := range f.deny {
+			if inSubtree(r, denied) {
+				return nil, errors.Wrapf(ErrOIDDenied, "%s contains %s", oid, denied)
+			}
+		}
+	}
+	return roots, nil
+}
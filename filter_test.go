@@ -0,0 +1,66 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestOIDFilter(t *testing.T) {
+	f, err := NewOIDFilter([]string{".1.3.6.1.2.1.2", ".1.3.6.1.2.1.31"}, []string{".1.3.6.1.2.1.2.2.1.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for oid, ok := range map[string]bool{
+		".1.3.6.1.2.1.2.2.1.10.1": true,
+		".1.3.6.1.2.1.2.2.1.2.1":  false,
+		".1.3.6.1.2.1.25.2.3":     false,
+		".1.3.6.1.2.1.22":         false,
+	} {
+		if f.Permits(oid) != ok {
+			t.Errorf("expected %s permitted to be %t", oid, ok)
+		}
+	}
+
+	if _, err := f.walkRoots(".1.3.6.1.2.1.2.2.1.10"); err != nil {
+		t.Errorf("expected allowed walk, got %v", err)
+	}
+	// the allowed ifTable has a denied column
+	if _, err := f.walkRoots(".1.3.6.1.2.1"); errors.Cause(err) != ErrOIDDenied {
+		t.Errorf("expected walk containing a denied subtree to be denied, got %v", err)
+	}
+	if _, err := f.walkRoots(".1.3.6.1.2.1.2.2.1.2"); errors.Cause(err) != ErrOIDDenied {
+		t.Errorf("expected denied walk, got %v", err)
+	}
+	if _, err := f.walkRoots(".1.3.6.1.2.1.25"); errors.Cause(err) != ErrOIDDenied {
+		t.Errorf("expected walk outside allowed subtrees to be denied, got %v", err)
+	}
+
+	pruned, err := NewOIDFilter([]string{".1.3.6.1.2.1.2", ".1.3.6.1.2.1.31"}, []string{".1.3.6.1.2.1.25"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots, err := pruned.walkRoots(".1.3.6.1.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 2 || roots[0] != ".1.3.6.1.2.1.2" {
+		t.Errorf("expected walk pruned to the allowed subtrees, got %v", roots)
+	}
+
+	s, err := NewSession(profileV2, WithOIDFilter(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, _, err := s.Get([]string{".1.3.6.1.2.1.2.2.1.2.1"}); errors.Cause(err) != ErrOIDDenied {
+		t.Errorf("expected denied get, got %v", err)
+	}
+	if _, err := s.Walk(".1.3.6.1.2.1.2"); errors.Cause(err) != ErrOIDDenied {
+		t.Errorf("expected denied walk, got %v", err)
+	}
+}
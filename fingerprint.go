@@ -0,0 +1,100 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+const (
+	sysDescr = ".1.3.6.1.2.1.1.1.0"
+	ifNumber = ".1.3.6.1.2.1.2.1.0"
+
+	// round trips averaged for the fingerprint's latency
+	fingerprintSamples = 3
+)
+
+// DeviceFingerprint identifies a device and how it was reached
+type DeviceFingerprint struct {
+	Version     string // the SNMP version that worked: "1", "2c" or "3"
+	SysObjectID string
+	SysDescr    string
+	EngineID    string // hex encoded, for SNMP v3 only
+	Interfaces  int
+	Latency     time.Duration // average round trip
+}
+
+// Fingerprint connects to the device and collects the facts wanted when
+// onboarding it. If the profile has no version, v2c and then v1 are tried.
+// The interface count is ifNumber, or the interfaces found by Interfaces
+// if the agent does not report it. A fingerprint is returned with what
+// was learned even if a later probe fails.
+func Fingerprint(p Profile, opts ...ClientOption) (DeviceFingerprint, error) {
+	versions := []string{p.Version}
+	if p.Version == "" {
+		versions = []string{"2c", "1"}
+	}
+	failed := []string{}
+	for _, version := range versions {
+		p.Version = version
+		client, err := NewClient(p, opts...)
+		var latency time.Duration
+		if err == nil {
+			_, _, latency, err = MeasureLatency(client, fingerprintSamples)
+		}
+		if err != nil {
+			if client != nil && client.Conn != nil {
+				client.Conn.Close()
+			}
+			failed = append(failed, fmt.Sprintf("version %s: %s", version, err))
+			continue
+		}
+		fp, err := fingerprint(client)
+		fp.Latency = latency
+		client.Conn.Close()
+		return fp, err
+	}
+	return DeviceFingerprint{}, errors.Errorf("no snmp version worked for host %s (%s)", p.Host, strings.Join(failed, "; "))
+}
+
+// fingerprint probes the client known to be reachable
+func fingerprint(client *gosnmp.GoSNMP) (DeviceFingerprint, error) {
+	var fp DeviceFingerprint
+	switch client.Version {
+	case gosnmp.Version1:
+		fp.Version = "1"
+	case gosnmp.Version2c:
+		fp.Version = "2c"
+	case gosnmp.Version3:
+		fp.Version = "3"
+		id, err := DeviceEngineID(client)
+		if err != nil {
+			return fp, err
+		}
+		fp.EngineID = hex.EncodeToString(id)
+	}
+	values, _, err := Get(client, []string{sysObjectID, sysDescr, ifNumber})
+	if err != nil && errors.Cause(err) != ErrPartialGet {
+		return fp, err
+	}
+	fp.SysObjectID, _ = values[sysObjectID].(string)
+	fp.SysDescr, _ = values[sysDescr].(string)
+	if n, ok := numericValue(values[ifNumber]); ok {
+		fp.Interfaces = int(n)
+		return fp, nil
+	}
+	ifaces, err := Interfaces(client)
+	if err != nil {
+		return fp, errors.Wrap(err, "count interfaces")
+	}
+	fp.Interfaces = len(ifaces)
+	return fp, nil
+}
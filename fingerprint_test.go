@@ -0,0 +1,100 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"net"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestFingerprint(t *testing.T) {
+	agent := replayer(t,
+		Exchange{
+			PDUType:  gosnmp.GetRequest,
+			OIDs:     []string{sysUpTime},
+			Response: []Record{{OID: sysUpTime, Type: int32(gosnmp.TimeTicks), IntVal: 1234}},
+		},
+		Exchange{
+			PDUType: gosnmp.GetRequest,
+			OIDs:    []string{sysObjectID, sysDescr, ifNumber},
+			Response: []Record{
+				{OID: sysObjectID, Type: int32(gosnmp.ObjectIdentifier), StrVal: ".1.3.6.1.4.1.8072.3.2.10"},
+				{OID: sysDescr, Type: int32(gosnmp.OctetString), BytesVal: []byte("Linux router1")},
+				{OID: ifNumber, Type: int32(gosnmp.Integer), IntVal: 4},
+			},
+		},
+	)
+	addr, err := agent.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := profileV2
+	p.Host, p.Port, p.Version = "127.0.0.1", addr.Port, ""
+
+	fp, err := Fingerprint(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := DeviceFingerprint{Version: "2c", SysObjectID: ".1.3.6.1.4.1.8072.3.2.10", SysDescr: "Linux router1", Interfaces: 4, Latency: fp.Latency}
+	if fp != expect {
+		t.Errorf("expected %+v, got %+v", expect, fp)
+	}
+	if fp.Latency <= 0 {
+		t.Errorf("expected a latency, got %s", fp.Latency)
+	}
+
+	// an agent that never answers has no fingerprint
+	drop := dropAgent(t, 1000)
+	defer drop.Close()
+	p.Port, p.Timeout, p.Retries = drop.LocalAddr().(*net.UDPAddr).Port, 1, 0
+	p.Version = "2c"
+	if _, err := Fingerprint(p); err == nil {
+		t.Error("expected an unreachable agent to fail")
+	}
+}
+
+func TestFingerprintFallback(t *testing.T) {
+	// an agent answering only SNMP v1, with integers
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+	go func() {
+		decoder := &gosnmp.GoSNMP{}
+		buf := make([]byte, maxTrapSize)
+		for {
+			n, addr, err := agent.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			req, err := decoder.SnmpDecodePacket(buf[:n])
+			if err != nil || req.Version != gosnmp.Version1 {
+				continue
+			}
+			resp := &gosnmp.SnmpPacket{Version: req.Version, Community: req.Community, PDUType: gosnmp.GetResponse, RequestID: req.RequestID}
+			for _, pdu := range req.Variables {
+				resp.Variables = append(resp.Variables, gosnmp.SnmpPDU{Name: pdu.Name, Type: gosnmp.Integer, Value: 4})
+			}
+			if b, err := resp.MarshalMsg(); err == nil {
+				agent.WriteTo(b, addr)
+			}
+		}
+	}()
+
+	p := profileV2
+	p.Host, p.Port, p.Version, p.Timeout, p.Retries = "127.0.0.1", agent.LocalAddr().(*net.UDPAddr).Port, "", 1, 0
+	// v2c fails finding a community, yet v1 is still tried
+	p.Communities = []string{p.Community}
+	fp, err := Fingerprint(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp.Version != "1" || fp.Interfaces != 4 {
+		t.Errorf("expected v1 with 4 interfaces, got %+v", fp)
+	}
+}
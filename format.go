@@ -0,0 +1,125 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/soniah/gosnmp"
+)
+
+// FormatVarbind renders a varbind the way net-snmp's tools do, e.g.,
+// "1.3.6.1.2.1.1.3.0 = Timeticks: (12345) 0:02:03.45". The oid is
+// printed as given (e.g., a name), or the pdu's OID if empty.
+func FormatVarbind(oid string, pdu gosnmp.SnmpPDU) string {
+	if len(oid) == 0 {
+		oid = pdu.Name
+	}
+	return oid + " = " + formatValue(pdu)
+}
+
+// WalkPrint walks the subtree at rootOID, writing each varbind to w in
+// snmpwalk's "OID = TYPE: value" format (see FormatVarbind).
+// Output is buffered and flushed once the walk is done.
+func WalkPrint(client *gosnmp.GoSNMP, rootOID string, w io.Writer) error {
+	oid, err := getOID(rootOID)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fn := func(pdu gosnmp.SnmpPDU) error {
+		_, err := bw.WriteString(FormatVarbind("", pdu) + "\n")
+		return err
+	}
+	err = walkFunc(client)(oid, fn)
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// formatValue renders the type and value of a pdu
+func formatValue(pdu gosnmp.SnmpPDU) string {
+	switch pdu.Type {
+	case gosnmp.Integer:
+		return "INTEGER: " + gosnmp.ToBigInt(pdu.Value).String()
+	case gosnmp.Counter32:
+		return "Counter32: " + gosnmp.ToBigInt(pdu.Value).String()
+	case gosnmp.Counter64:
+		return "Counter64: " + gosnmp.ToBigInt(pdu.Value).String()
+	case gosnmp.Gauge32:
+		return "Gauge32: " + gosnmp.ToBigInt(pdu.Value).String()
+	case gosnmp.Uinteger32:
+		return "UInteger32: " + gosnmp.ToBigInt(pdu.Value).String()
+	case gosnmp.TimeTicks:
+		ticks := gosnmp.ToBigInt(pdu.Value).Uint64()
+		return fmt.Sprintf("Timeticks: (%d) %s", ticks, formatTicks(ticks))
+	case gosnmp.IPAddress:
+		return fmt.Sprintf("IpAddress: %v", pdu.Value)
+	case gosnmp.ObjectIdentifier:
+		return fmt.Sprintf("OID: %v", pdu.Value)
+	case gosnmp.OctetString:
+		b, _ := pdu.Value.([]byte)
+		if isPrintable(b) {
+			return fmt.Sprintf("STRING: %q", b)
+		}
+		return "Hex-STRING: " + hexOctets(b)
+	case gosnmp.BitString:
+		b, _ := pdu.Value.([]byte)
+		return "BITS: " + hexOctets(b)
+	case gosnmp.Opaque:
+		if b, ok := pdu.Value.([]byte); ok {
+			return "Opaque: " + hexOctets(b)
+		}
+		return fmt.Sprintf("Opaque: %v", pdu.Value)
+	case gosnmp.Null:
+		return "NULL"
+	case gosnmp.NoSuchObject:
+		return "No Such Object available on this agent at this OID"
+	case gosnmp.NoSuchInstance:
+		return "No Such Instance currently exists at this OID"
+	case gosnmp.EndOfMibView:
+		return "No more variables left in this MIB View (It is past the end of the MIB tree)"
+	}
+	return fmt.Sprintf("%s: %v", pdu.Type, pdu.Value)
+}
+
+// formatTicks renders hundredths of a second as [N days, ]H:MM:SS.hh
+func formatTicks(ticks uint64) string {
+	days := ticks / 8640000
+	hours := ticks / 360000 % 24
+	minutes := ticks / 6000 % 60
+	seconds := ticks / 100 % 60
+	s := fmt.Sprintf("%d:%02d:%02d.%02d", hours, minutes, seconds, ticks%100)
+	switch days {
+	case 0:
+		return s
+	case 1:
+		return "1 day, " + s
+	}
+	return fmt.Sprintf("%d days, %s", days, s)
+}
+
+// isPrintable returns whether the octets are text to be shown as is
+func isPrintable(b []byte) bool {
+	for _, c := range b {
+		if (c < ' ' || c > '~') && c != '\t' && c != '\r' && c != '\n' {
+			return false
+		}
+	}
+	return true
+}
+
+// hexOctets renders octets as space separated hex, e.g., "00 1A 2B"
+func hexOctets(b []byte) string {
+	octets := make([]string, len(b))
+	for i, c := range b {
+		octets[i] = fmt.Sprintf("%02X", c)
+	}
+	return strings.Join(octets, " ")
+}
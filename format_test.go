@@ -0,0 +1,48 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestFormatVarbind(t *testing.T) {
+	for _, tc := range []struct {
+		pdu  gosnmp.SnmpPDU
+		want string
+	}{
+		{gosnmp.SnmpPDU{Name: sysUpTime, Type: gosnmp.TimeTicks, Value: uint32(12345)}, sysUpTime + " = Timeticks: (12345) 0:02:03.45"},
+		{gosnmp.SnmpPDU{Name: sysUpTime, Type: gosnmp.TimeTicks, Value: uint32(123456789)}, sysUpTime + " = Timeticks: (123456789) 14 days, 6:56:07.89"},
+		{gosnmp.SnmpPDU{Name: sysName, Type: gosnmp.OctetString, Value: []byte("router1")}, sysName + ` = STRING: "router1"`},
+		{gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.2.2.1.6.1", Type: gosnmp.OctetString, Value: []byte{0, 0x50, 0x56, 0xab}}, ".1.3.6.1.2.1.2.2.1.6.1 = Hex-STRING: 00 50 56 AB"},
+		{gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.31.1.1.1.6.1", Type: gosnmp.Counter64, Value: uint64(1 << 40)}, ".1.3.6.1.2.1.31.1.1.1.6.1 = Counter64: 1099511627776"},
+		{gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.4.20.1.1.10.0.0.1", Type: gosnmp.IPAddress, Value: "10.0.0.1"}, ".1.3.6.1.2.1.4.20.1.1.10.0.0.1 = IpAddress: 10.0.0.1"},
+		{gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.1.2.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.4.1.9"}, ".1.3.6.1.2.1.1.2.0 = OID: .1.3.6.1.4.1.9"},
+		{gosnmp.SnmpPDU{Name: ".1.3.6.1.2.1.2.2.1.8.1", Type: gosnmp.Integer, Value: 1}, ".1.3.6.1.2.1.2.2.1.8.1 = INTEGER: 1"},
+	} {
+		if s := FormatVarbind("", tc.pdu); s != tc.want {
+			t.Errorf("expected %q, got %q", tc.want, s)
+		}
+	}
+	if s := FormatVarbind("sysName.0", gosnmp.SnmpPDU{Name: sysName, Type: gosnmp.NoSuchObject}); s != "sysName.0 = No Such Object available on this agent at this OID" {
+		t.Errorf("unexpected format: %q", s)
+	}
+}
+
+func TestWalkPrint(t *testing.T) {
+	client := replayAgent(t, ifDescrTranscript)
+
+	var b bytes.Buffer
+	if err := WalkPrint(client, ifDescr, &b); err != nil {
+		t.Fatal(err)
+	}
+	expect := ifDescr + ".1 = STRING: \"lo\"\n" + ifDescr + ".2 = STRING: \"eth0\"\n"
+	if b.String() != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, b.String())
+	}
+}
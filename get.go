@@ -0,0 +1,224 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+var (
+	// ErrPartialGet is returned by Get when some of the requested OIDs failed
+	ErrPartialGet = errors.New("not all OIDs could be retrieved")
+
+	// ErrTruncated is reported by Get for OIDs missing from an agent's response
+	ErrTruncated = errors.New("value missing from truncated response")
+
+	// ErrNoSuchObject is returned by GetScalar when the agent has no such value
+	ErrNoSuchObject = errors.New("no such object")
+)
+
+// chunkOIDs splits oids into groups of at most size entries
+func chunkOIDs(oids []string, size int) [][]string {
+	if size < 1 {
+		size = gosnmp.MaxOids
+	}
+	chunks := make([][]string, 0, (len(oids)+size-1)/size)
+	for len(oids) > size {
+		chunks = append(chunks, oids[:size])
+		oids = oids[size:]
+	}
+	if len(oids) > 0 {
+		chunks = append(chunks, oids)
+	}
+	return chunks
+}
+
+// GetOption adjusts the behavior of Get
+type GetOption func(*getOptions)
+
+type getOptions struct {
+	retryTruncated bool
+}
+
+// RetryTruncated re-requests the OIDs missing from a response, for agents
+// that silently return fewer varbinds than were asked for. Without it,
+// the missing OIDs are reported as failed with ErrTruncated.
+func RetryTruncated() GetOption {
+	return func(o *getOptions) {
+		o.retryTruncated = true
+	}
+}
+
+// Get returns the values of the given OIDs, keyed by OID.
+// Requests larger than the client's MaxOids are split into as many
+// GETs as needed. A failed chunk does not discard the others; the OIDs
+// that could not be retrieved are returned with their errors, along
+// with ErrPartialGet. An OID the agent names in an error response (e.g.,
+// a v1 noSuchName, reported as ErrNoSuchObject) fails with that error,
+// and the rest of its chunk is requested again.
+//
+// Chunks are sent sequentially as a gosnmp client is not safe for
+// concurrent use.
+func Get(client *gosnmp.GoSNMP, oids []string, opts ...GetOption) (map[string]interface{}, map[string]error, error) {
+	var options getOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if err := connected(client); err != nil {
+		return nil, nil, err
+	}
+	results := make(map[string]interface{})
+	failed := make(map[string]error)
+
+	fixed := make([]string, 0, len(oids))
+	for _, oid := range oids {
+		o, err := getOID(oid)
+		if err != nil {
+			failed[oid] = err
+			continue
+		}
+		fixed = append(fixed, o)
+	}
+
+	for _, chunk := range chunkOIDs(fixed, client.MaxOids) {
+		for len(chunk) > 0 {
+			missing := getChunk(client, chunk, results, failed)
+			// give up on an agent that returned nothing we asked for
+			if !options.retryTruncated || len(missing) == len(chunk) {
+				for _, oid := range missing {
+					failed[oid] = ErrTruncated
+				}
+				break
+			}
+			chunk = missing
+		}
+	}
+
+	if len(failed) > 0 {
+		return results, failed, ErrPartialGet
+	}
+	return results, failed, nil
+}
+
+// getChunk gets the OIDs, adding their values to results or their errors
+// to failed, and returns those the agent left out of its response
+func getChunk(client *gosnmp.GoSNMP, chunk []string, results map[string]interface{}, failed map[string]error) []string {
+	packet, err := GetRaw(client, chunk)
+	if err != nil {
+		for _, oid := range chunk {
+			failed[oid] = err
+		}
+		return nil
+	}
+	if packet.Error != gosnmp.NoError {
+		return chunkFailed(client, chunk, packet, results, failed)
+	}
+	seen := make(map[string]struct{}, len(packet.Variables))
+	for _, pdu := range packet.Variables {
+		seen[pdu.Name] = struct{}{}
+		value, err := pduType(pdu)
+		if err != nil {
+			failed[pdu.Name] = err
+			continue
+		}
+		results[pdu.Name] = value
+	}
+	var missing []string
+	for _, oid := range chunk {
+		if _, ok := seen[oid]; !ok {
+			missing = append(missing, oid)
+		}
+	}
+	return missing
+}
+
+// chunkFailed handles a response to the chunk with an error-status set,
+// which carries no values. The OID named by its error-index fails with the
+// error and the rest are requested again; a chunk too big for the agent is
+// requested in halves. Without an OID to blame, the whole chunk fails.
+func chunkFailed(client *gosnmp.GoSNMP, chunk []string, packet *gosnmp.SnmpPacket, results map[string]interface{}, failed map[string]error) []string {
+	index := int(packet.ErrorIndex)
+	switch {
+	case packet.Error == gosnmp.TooBig && len(chunk) > 1:
+		half := len(chunk) / 2
+		missing := getChunk(client, chunk[:half], results, failed)
+		return append(missing, getChunk(client, chunk[half:], results, failed)...)
+	case index >= 1 && index <= len(chunk):
+		oid := chunk[index-1]
+		failed[oid] = getError(oid, packet.Error)
+		rest := append(chunk[:index-1:index-1], chunk[index:]...)
+		if len(rest) == 0 {
+			return nil
+		}
+		return getChunk(client, rest, results, failed)
+	}
+	for _, oid := range chunk {
+		failed[oid] = getError(oid, packet.Error)
+	}
+	return nil
+}
+
+// getError is the error for a GET of oid failing with status
+func getError(oid string, status gosnmp.SNMPError) error {
+	if status == gosnmp.NoSuchName {
+		return ErrNoSuchObject
+	}
+	return errors.Errorf("get %s failed: %s", oid, status)
+}
+
+// scalarOID resolves oid and appends the .0 instance if missing
+func scalarOID(oid string) (string, error) {
+	if len(oid) > 0 && unicode.IsDigit(rune(oid[0])) {
+		oid = "." + oid
+	}
+	oid, err := getOID(oid)
+	if err != nil {
+		return oid, err
+	}
+	if !strings.HasSuffix(oid, ".0") {
+		oid += ".0"
+	}
+	return oid, nil
+}
+
+// GetScalar returns the decoded value of a scalar, which may be given by
+// name or OID, with or without its .0 instance (e.g., "sysDescr").
+// ErrNoSuchObject is returned if the agent has no such value.
+func GetScalar(client *gosnmp.GoSNMP, oid string) (interface{}, error) {
+	oid, err := scalarOID(oid)
+	if err != nil {
+		return nil, err
+	}
+	pdu, err := getPDU(client, oid)
+	if err != nil {
+		return nil, err
+	}
+	return pduType(pdu)
+}
+
+// getPDU gets the undecoded value of a single resolved oid, returning
+// ErrNoSuchObject if the agent has no such value
+func getPDU(client *gosnmp.GoSNMP, oid string) (gosnmp.SnmpPDU, error) {
+	packet, err := GetRaw(client, []string{oid})
+	if err != nil {
+		return gosnmp.SnmpPDU{}, err
+	}
+	if packet.Error != gosnmp.NoError {
+		return gosnmp.SnmpPDU{}, getError(oid, packet.Error)
+	}
+	if len(packet.Variables) != 1 {
+		return gosnmp.SnmpPDU{}, errors.Errorf("expected 1 value for %s, got %d", oid, len(packet.Variables))
+	}
+	pdu := packet.Variables[0]
+	switch pdu.Type {
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView:
+		return gosnmp.SnmpPDU{}, ErrNoSuchObject
+	}
+	return pdu, nil
+}
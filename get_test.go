@@ -0,0 +1,147 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestChunkOIDs(t *testing.T) {
+	oids := make([]string, 0, 130)
+	for i := 0; i < cap(oids); i++ {
+		oids = append(oids, ".1.3.6.1.2.1.1."+strconv.Itoa(i))
+	}
+	chunks := chunkOIDs(oids, 60)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	total := 0
+	for _, c := range chunks {
+		if len(c) > 60 {
+			t.Errorf("chunk too large: %d", len(c))
+		}
+		total += len(c)
+	}
+	if total != len(oids) {
+		t.Errorf("expected %d oids, got %d", len(oids), total)
+	}
+	if got := chunkOIDs(nil, 60); len(got) != 0 {
+		t.Errorf("expected no chunks, got %d", len(got))
+	}
+}
+
+func TestScalarOID(t *testing.T) {
+	tests := []struct{ in, out string }{
+		{"1.3.6.1.2.1.1.1", ".1.3.6.1.2.1.1.1.0"},
+		{".1.3.6.1.2.1.1.1", ".1.3.6.1.2.1.1.1.0"},
+		{".1.3.6.1.2.1.1.1.0", ".1.3.6.1.2.1.1.1.0"},
+	}
+	for _, test := range tests {
+		oid, err := scalarOID(test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if oid != test.out {
+			t.Errorf("expected %s for %s, got %s", test.out, test.in, oid)
+		}
+	}
+}
+
+func TestGetScalar(t *testing.T) {
+	client := replayAgent(t, Exchange{
+		PDUType:  gosnmp.GetRequest,
+		OIDs:     []string{sysName},
+		Response: []Record{{OID: sysName, Type: int32(gosnmp.OctetString), BytesVal: []byte("router1")}},
+	})
+
+	v, err := GetScalar(client, sysName[1:len(sysName)-2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "router1" {
+		t.Errorf("expected router1, got %v", v)
+	}
+	if _, err := GetScalar(client, sysUpTime); err != ErrNoSuchObject {
+		t.Errorf("expected ErrNoSuchObject, got %v", err)
+	}
+}
+
+func TestGetTruncated(t *testing.T) {
+	oids := []string{".1.3.6.1.2.1.1.1.0", ".1.3.6.1.2.1.1.5.0", ".1.3.6.1.2.1.1.6.0"}
+	str := func(oid string) Record {
+		return Record{OID: oid, Type: int32(gosnmp.OctetString), BytesVal: []byte("x")}
+	}
+	// an agent that only returns the first varbind of each request
+	var exchanges []Exchange
+	for i := range oids {
+		exchanges = append(exchanges, Exchange{
+			PDUType:  gosnmp.GetRequest,
+			OIDs:     oids[i:],
+			Response: []Record{str(oids[i])},
+		})
+	}
+	client := replayAgent(t, exchanges...)
+
+	values, failed, err := Get(client, oids)
+	if err != ErrPartialGet {
+		t.Errorf("expected partial get, got %v", err)
+	}
+	if len(values) != 1 || failed[oids[1]] != ErrTruncated || failed[oids[2]] != ErrTruncated {
+		t.Errorf("expected truncated OIDs to be reported, got %v %v", values, failed)
+	}
+
+	values, failed, err = Get(client, oids, RetryTruncated())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 3 || len(failed) != 0 {
+		t.Errorf("expected all values after retries, got %v %v", values, failed)
+	}
+}
+
+func TestGetErrorIndex(t *testing.T) {
+	oids := []string{".1.3.6.1.2.1.1.1.0", ".1.3.6.1.2.1.1.5.0", ".1.3.6.1.2.1.1.6.0"}
+	str := func(oid string) Record {
+		return Record{OID: oid, Type: int32(gosnmp.OctetString), BytesVal: []byte("x")}
+	}
+	// error responses echo the varbinds of the request
+	echo := func(oids ...string) []Record {
+		var records []Record
+		for _, oid := range oids {
+			records = append(records, Record{OID: oid, Type: int32(gosnmp.Null)})
+		}
+		return records
+	}
+	// a v1 agent without the second OID, then failing on the location
+	client := replayAgent(t,
+		Exchange{PDUType: gosnmp.GetRequest, OIDs: oids, Error: gosnmp.NoSuchName, ErrorIndex: 2, Response: echo(oids...)},
+		Exchange{
+			PDUType:    gosnmp.GetRequest,
+			OIDs:       []string{oids[0], oids[2]},
+			Error:      gosnmp.GenErr,
+			ErrorIndex: 2,
+			Response:   echo(oids[0], oids[2]),
+		},
+		Exchange{PDUType: gosnmp.GetRequest, OIDs: oids[:1], Response: []Record{str(oids[0])}},
+	)
+
+	values, failed, err := Get(client, oids)
+	if err != ErrPartialGet {
+		t.Errorf("expected partial get, got %v", err)
+	}
+	if len(values) != 1 || values[oids[0]] != "x" {
+		t.Errorf("unexpected values: %v", values)
+	}
+	if len(failed) != 2 || failed[oids[1]] != ErrNoSuchObject {
+		t.Errorf("expected noSuchName for %s, got %v", oids[1], failed)
+	}
+	if err := failed[oids[2]]; err == nil || !strings.Contains(err.Error(), oids[2]) {
+		t.Errorf("expected genErr for %s, got %v", oids[2], err)
+	}
+}
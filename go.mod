@@ -0,0 +1,11 @@
+module github.com/paulstuart/snmputil
+
+go 1.16
+
+replace github.com/soniah/gosnmp => github.com/gosnmp/gosnmp v1.26.0
+
+require (
+	github.com/hashicorp/go-immutable-radix v1.3.1
+	github.com/pkg/errors v0.9.1
+	github.com/soniah/gosnmp v0.0.0-00010101000000-000000000000
+)
@@ -0,0 +1,230 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// ErrUnsupported is returned when none of the known MIBs for a value
+// are supported by the agent
+var ErrUnsupported = errors.New("no supported MIB")
+
+const (
+	sysObjectID = ".1.3.6.1.2.1.1.2.0"
+
+	// CISCO-PROCESS-MIB and CISCO-MEMORY-POOL-MIB
+	cpmCPUTotal5minRev  = ".1.3.6.1.4.1.9.9.109.1.1.1.1.8"
+	ciscoMemoryPoolUsed = ".1.3.6.1.4.1.9.9.48.1.1.1.5"
+	ciscoMemoryPoolFree = ".1.3.6.1.4.1.9.9.48.1.1.1.6"
+
+	// UCD-SNMP-MIB
+	ssCpuIdle    = ".1.3.6.1.4.1.2021.11.11.0"
+	memTotalReal = ".1.3.6.1.4.1.2021.4.5.0"
+	memAvailReal = ".1.3.6.1.4.1.2021.4.6.0"
+	memBuffer    = ".1.3.6.1.4.1.2021.4.14.0"
+	memCached    = ".1.3.6.1.4.1.2021.4.15.0"
+
+	// HOST-RESOURCES-MIB
+	hrProcessorLoad = ".1.3.6.1.2.1.25.3.3.1.2"
+	hrStorageRam    = ".1.3.6.1.2.1.25.2.1.2"
+)
+
+// utilization reads CPU or memory utilization from one MIB, failing with
+// ErrNoSuchObject if the agent does not support it
+type utilization func(*gosnmp.GoSNMP) (float64, error)
+
+// utilizationMIBs are the MIBs tried for CPU and memory, by hint
+var utilizationMIBs = map[string]struct{ cpu, mem utilization }{
+	"cisco":          {ciscoCPU, ciscoMemory},
+	"net-snmp":       {ucdCPU, ucdMemory},
+	"host-resources": {hrCPU, hrMemory},
+}
+
+// utilizationOrder is the order the MIBs are tried without a hint
+var utilizationOrder = []string{"cisco", "net-snmp", "host-resources"}
+
+// Utilization returns the CPU and memory utilization of the device as
+// percentages. The vendorHint ("cisco", "net-snmp" or "host-resources")
+// selects the MIB tried first; if empty, the vendor is detected from the
+// sysObjectID. Other MIBs are tried in turn if the first is unsupported.
+// ErrUnsupported is returned if no known MIB has either value, along
+// with the one that was found, if any.
+func Utilization(client *gosnmp.GoSNMP, vendorHint string) (cpuPct, memPct float64, err error) {
+	hint := strings.ToLower(vendorHint)
+	if len(hint) == 0 {
+		hint = utilizationVendor(client)
+	}
+	order := utilizationOrder
+	if _, ok := utilizationMIBs[hint]; ok {
+		order = []string{hint}
+		for _, name := range utilizationOrder {
+			if name != hint {
+				order = append(order, name)
+			}
+		}
+	}
+
+	try := func(what string, pick func(string) utilization) (float64, error) {
+		for _, name := range order {
+			pct, err := pick(name)(client)
+			if err == nil {
+				return pct, nil
+			}
+			if err != ErrNoSuchObject {
+				return 0, errors.Wrapf(err, "%s %s", name, what)
+			}
+		}
+		return 0, errors.Wrapf(ErrUnsupported, "no %s utilization", what)
+	}
+	cpuPct, cpuErr := try("cpu", func(name string) utilization { return utilizationMIBs[name].cpu })
+	if cpuErr != nil && errors.Cause(cpuErr) != ErrUnsupported {
+		return 0, 0, cpuErr
+	}
+	memPct, memErr := try("memory", func(name string) utilization { return utilizationMIBs[name].mem })
+	if cpuErr != nil {
+		return cpuPct, memPct, cpuErr
+	}
+	return cpuPct, memPct, memErr
+}
+
+// utilizationVendor returns the hint for the device's vendor, if known
+func utilizationVendor(client *gosnmp.GoSNMP) string {
+	v, err := GetScalar(client, sysObjectID)
+	if err != nil {
+		return ""
+	}
+	oid, _ := v.(string)
+	switch n, _ := VendorFromSysObjectID(oid); n {
+	case 9:
+		return "cisco"
+	case 8072:
+		return "net-snmp"
+	}
+	return ""
+}
+
+// numericValue returns a decoded numeric value as a float
+func numericValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// sumColumn returns the total and number of the numeric values of a
+// column, failing with ErrNoSuchObject if there are none
+func sumColumn(client *gosnmp.GoSNMP, column string) (float64, int, error) {
+	values, err := Walk(client, column)
+	if err != nil {
+		return 0, 0, err
+	}
+	var sum float64
+	var count int
+	for _, v := range values {
+		if f, ok := numericValue(v); ok {
+			sum += f
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, 0, ErrNoSuchObject
+	}
+	return sum, count, nil
+}
+
+// averageColumn returns the average of the numeric values of a column
+func averageColumn(client *gosnmp.GoSNMP, column string) (float64, error) {
+	sum, count, err := sumColumn(client, column)
+	if err != nil {
+		return 0, err
+	}
+	return sum / float64(count), nil
+}
+
+func ciscoCPU(client *gosnmp.GoSNMP) (float64, error) {
+	return averageColumn(client, cpmCPUTotal5minRev)
+}
+
+func ciscoMemory(client *gosnmp.GoSNMP) (float64, error) {
+	used, _, err := sumColumn(client, ciscoMemoryPoolUsed)
+	if err != nil {
+		return 0, err
+	}
+	free, _, err := sumColumn(client, ciscoMemoryPoolFree)
+	if err != nil {
+		return 0, err
+	}
+	return percent(used, used+free)
+}
+
+func ucdCPU(client *gosnmp.GoSNMP) (float64, error) {
+	v, err := GetScalar(client, ssCpuIdle)
+	if err != nil {
+		return 0, err
+	}
+	idle, ok := numericValue(v)
+	if !ok {
+		return 0, errors.Errorf("invalid ssCpuIdle value: %v", v)
+	}
+	return 100 - idle, nil
+}
+
+func ucdMemory(client *gosnmp.GoSNMP) (float64, error) {
+	values, _, err := Get(client, []string{memTotalReal, memAvailReal, memBuffer, memCached})
+	if err != nil && err != ErrPartialGet {
+		return 0, err
+	}
+	total, ok := numericValue(values[memTotalReal])
+	avail, ok2 := numericValue(values[memAvailReal])
+	if !ok || !ok2 {
+		return 0, ErrNoSuchObject
+	}
+	// buffers and cache are reclaimable, so not in use
+	buffer, _ := numericValue(values[memBuffer])
+	cached, _ := numericValue(values[memCached])
+	return percent(total-avail-buffer-cached, total)
+}
+
+func hrCPU(client *gosnmp.GoSNMP) (float64, error) {
+	return averageColumn(client, hrProcessorLoad)
+}
+
+func hrMemory(client *gosnmp.GoSNMP) (float64, error) {
+	storage, err := StorageTable(client)
+	if err != nil {
+		return 0, err
+	}
+	var used, total uint64
+	for _, s := range storage {
+		if s.Type == hrStorageRam {
+			used += s.UsedBytes
+			total += s.TotalBytes
+		}
+	}
+	return percent(float64(used), float64(total))
+}
+
+// percent returns part as a percentage of whole
+func percent(part, whole float64) (float64, error) {
+	if whole <= 0 {
+		return 0, ErrNoSuchObject
+	}
+	return 100 * part / whole, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+func TestUtilization(t *testing.T) {
+	gauge := func(oid string, v int64) Record {
+		return Record{OID: oid, Type: int32(gosnmp.Gauge32), IntVal: v}
+	}
+	integer := func(oid string, v int64) Record {
+		return Record{OID: oid, Type: int32(gosnmp.Integer), IntVal: v}
+	}
+	bulk := func(oid string, records ...Record) Exchange {
+		return Exchange{PDUType: gosnmp.GetBulkRequest, OIDs: []string{oid}, MaxRepetitions: defaultRepetitions, Response: records}
+	}
+	client := replayAgent(t,
+		// net-snmp, detected from the sysObjectID
+		Exchange{PDUType: gosnmp.GetRequest, OIDs: []string{sysObjectID}, Response: []Record{{OID: sysObjectID, Type: int32(gosnmp.ObjectIdentifier), StrVal: ".1.3.6.1.4.1.8072.3.2.10"}}},
+		Exchange{PDUType: gosnmp.GetRequest, OIDs: []string{ssCpuIdle}, Response: []Record{integer(ssCpuIdle, 93)}},
+		Exchange{PDUType: gosnmp.GetRequest, OIDs: []string{memTotalReal, memAvailReal, memBuffer, memCached}, Response: []Record{
+			integer(memTotalReal, 1000), integer(memAvailReal, 200), integer(memBuffer, 100), integer(memCached, 200),
+		}},
+		// cisco, by hint
+		bulk(cpmCPUTotal5minRev, gauge(cpmCPUTotal5minRev+".1", 10), gauge(cpmCPUTotal5minRev+".2", 20), gauge(ciscoMemoryPoolUsed+".1", 0)),
+		bulk(ciscoMemoryPoolUsed, gauge(ciscoMemoryPoolUsed+".1", 300), gauge(ciscoMemoryPoolUsed+".2", 100), gauge(ciscoMemoryPoolFree+".1", 0)),
+		bulk(ciscoMemoryPoolFree, gauge(ciscoMemoryPoolFree+".1", 500), gauge(ciscoMemoryPoolFree+".2", 100), gauge(".1.3.6.1.4.1.9.9.48.1.1.1.7.1", 0)),
+	)
+
+	for _, test := range []struct {
+		hint     string
+		cpu, mem float64
+	}{
+		{"", 7, 50},
+		{"Cisco", 15, 40},
+	} {
+		cpu, mem, err := Utilization(client, test.hint)
+		if err != nil {
+			t.Fatalf("hint %q: %v", test.hint, err)
+		}
+		if cpu != test.cpu || mem != test.mem {
+			t.Errorf("hint %q: expected cpu %v%% mem %v%%, got %v%% %v%%", test.hint, test.cpu, test.mem, cpu, mem)
+		}
+	}
+
+	client = replayAgent(t)
+	if _, _, err := Utilization(client, "net-snmp"); errors.Cause(err) != ErrUnsupported {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrDeadlineExceeded is the error of hosts not polled by PollHostsDeadline's deadline
+var ErrDeadlineExceeded = errors.New("poll deadline exceeded")
+
+// HostResult is the outcome of polling a single host
+type HostResult struct {
+	Host   string
+	Values map[string]interface{}
+	Failed map[string]error // OIDs that could not be retrieved
+	Err    error
+	index  int // of the host's profile
+}
+
+// pollHost gets the oids from the host specified in the profile
+func pollHost(ctx context.Context, p Profile, oids []string) HostResult {
+	r := HostResult{Host: p.Host}
+	if r.Err = ctx.Err(); r.Err != nil {
+		return r
+	}
+	client, err := NewClient(p)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	defer client.Conn.Close()
+	if budget := retryBudget(ctx); budget != nil {
+		LimitRetries(client, budget)
+		defer LimitRetries(client, nil)
+	}
+	r.Err = withContext(ctx, client, func() error {
+		var err error
+		r.Values, r.Failed, err = Get(client, oids)
+		return err
+	})
+	return r
+}
+
+// PollHosts gets the oids from each of the hosts, polling up to concurrency
+// hosts at a time. Results are sent on the returned channel as each host
+// completes and it is closed once all hosts are done. If ctx is cancelled,
+// hosts not yet polled are sent with the context's error. If ctx has a
+// RetryBudget (see WithRetryBudget), the retries of all hosts draw from it.
+func PollHosts(ctx context.Context, profiles []Profile, oids []string, concurrency int) <-chan HostResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make(chan HostResult, concurrency)
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				r := pollHost(ctx, profiles[i], oids)
+				r.index = i
+				results <- r
+			}
+		}()
+	}
+	go func() {
+		for i := range profiles {
+			queue <- i
+		}
+		close(queue)
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// PollHostsFunc is PollHosts with fn applied to each result as it completes.
+// fn is always called from the same goroutine so it need not be safe
+// for concurrent use. It returns once all hosts are done, with the
+// context's error if it was cancelled.
+func PollHostsFunc(ctx context.Context, profiles []Profile, oids []string, concurrency int, fn func(HostResult)) error {
+	for r := range PollHosts(ctx, profiles, oids, concurrency) {
+		fn(r)
+	}
+	return ctx.Err()
+}
+
+// PollHostsDeadline is PollHosts with the whole poll bounded by deadline.
+// Hosts still outstanding at the deadline are cancelled and sent with
+// ErrDeadlineExceeded, while those that completed are sent with their data.
+func PollHostsDeadline(ctx context.Context, profiles []Profile, oids []string, concurrency int, deadline time.Time) <-chan HostResult {
+	dctx, cancel := context.WithDeadline(ctx, deadline)
+	polled := PollHosts(dctx, profiles, oids, concurrency)
+	results := make(chan HostResult, cap(polled))
+	go func() {
+		defer cancel()
+		for r := range polled {
+			// the socket deadline is the same instant, and may fire before
+			// dctx notices, so judge by the clock; a deadline of the parent
+			// context is its own to report
+			if r.Err != nil && !time.Now().Before(deadline) && ctx.Err() == nil {
+				r.Err = ErrDeadlineExceeded
+			}
+			results <- r
+		}
+		close(results)
+	}()
+	return results
+}
+
+// ScalarRow is the scalars collected from a host by CollectScalars,
+// keyed by the OIDs as they were given
+type ScalarRow struct {
+	Host   string
+	Values map[string]interface{}
+	Err    error
+}
+
+// CollectScalars gets the scalars (e.g., "sysName" or ".1.3.6.1.2.1.1.3.0",
+// see GetScalar) from each of the hosts using PollHosts, returning a row
+// per profile in the same order. A row's Err is that of its host, which
+// is ErrPartialGet if only some of the scalars were found. The error
+// returned is the context's, if it was cancelled.
+func CollectScalars(ctx context.Context, profiles []Profile, oids []string, concurrency int) ([]ScalarRow, error) {
+	scalars := make([]string, len(oids))
+	keys := make(map[string]string, len(oids))
+	for i, oid := range oids {
+		s, err := scalarOID(oid)
+		if err != nil {
+			return nil, err
+		}
+		scalars[i], keys[s] = s, oid
+	}
+	rows := make([]ScalarRow, len(profiles))
+	err := PollHostsFunc(ctx, profiles, scalars, concurrency, func(r HostResult) {
+		row := &rows[r.index]
+		row.Host, row.Err = r.Host, r.Err
+		row.Values = make(map[string]interface{}, len(r.Values))
+		for oid, v := range r.Values {
+			row.Values[keys[oid]] = v
+		}
+	})
+	return rows, err
+}
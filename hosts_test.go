@@ -0,0 +1,94 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestPollHostsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	profiles := []Profile{profileV2, profileV2, profileV3}
+	count := 0
+	err := PollHostsFunc(ctx, profiles, []string{sysName}, 2, func(r HostResult) {
+		count++
+		if r.Err != context.Canceled {
+			t.Errorf("expected cancelled result, got: %v", r.Err)
+		}
+	})
+	if err != context.Canceled {
+		t.Errorf("expected cancelled error, got: %v", err)
+	}
+	if count != len(profiles) {
+		t.Errorf("expected %d results, got %d", len(profiles), count)
+	}
+}
+
+func TestPollHostsDeadline(t *testing.T) {
+	// the agent never answers, so the hosts are outstanding at the deadline
+	agent := dropAgent(t, 1000)
+	defer agent.Close()
+	p := profileV2
+	p.Host, p.Port, p.Timeout = "127.0.0.1", agent.LocalAddr().(*net.UDPAddr).Port, 5
+	start := time.Now()
+	count := 0
+	for r := range PollHostsDeadline(context.Background(), []Profile{p, p, p}, []string{sysName}, 2, start.Add(100*time.Millisecond)) {
+		count++
+		if r.Err != ErrDeadlineExceeded {
+			t.Errorf("expected deadline exceeded, got: %v", r.Err)
+		}
+	}
+	if count != 3 {
+		t.Errorf("expected 3 results, got %d", count)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("expected poll to end at the deadline, took %s", d)
+	}
+}
+
+func TestCollectScalars(t *testing.T) {
+	var profiles []Profile
+	for _, name := range []string{"router1", "router2", "router3"} {
+		rp := replayer(t, Exchange{
+			PDUType: gosnmp.GetRequest,
+			OIDs:    []string{sysName, sysUpTime},
+			Response: []Record{
+				{OID: sysName, Type: int32(gosnmp.OctetString), BytesVal: []byte(name)},
+				{OID: sysUpTime, Type: int32(gosnmp.TimeTicks), IntVal: 100},
+			},
+		})
+		addr, err := rp.Listen("127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := profileV2
+		p.Host, p.Port = "127.0.0.1", addr.Port
+		profiles = append(profiles, p)
+	}
+	// the .0 instance is optional
+	oids := []string{sysName, sysUpTime[:len(sysUpTime)-2]}
+	rows, err := CollectScalars(context.Background(), profiles, oids, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(rows))
+	}
+	for i, row := range rows {
+		if row.Err != nil {
+			t.Errorf("row %d: %v", i, row.Err)
+		}
+		if name := fmt.Sprintf("router%d", i+1); row.Values[sysName] != name || row.Values[oids[1]] != uint32(100) {
+			t.Errorf("row %d: expected %s, got %v", i, name, row.Values)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// indexOctets parses the first n subidentifiers of an index as octets,
+// returning them and the remaining index
+func indexOctets(suffix string, n int) ([]byte, string, error) {
+	bits := strings.SplitN(strings.TrimPrefix(suffix, "."), ".", n+1)
+	if len(bits) < n {
+		return nil, suffix, errors.Errorf("index %s is shorter than %d subidentifiers", suffix, n)
+	}
+	octets := make([]byte, n)
+	for i := 0; i < n; i++ {
+		v, err := strconv.Atoi(bits[i])
+		if err != nil || v < 0 || v > 255 {
+			return nil, suffix, errors.Errorf("invalid octet %q in index %s", bits[i], suffix)
+		}
+		octets[i] = byte(v)
+	}
+	var rest string
+	if len(bits) > n {
+		rest = bits[n]
+	}
+	return octets, rest, nil
+}
+
+// IndexToIP parses the IP address of size octets (net.IPv4len or
+// net.IPv6len) at the start of a table index, returning the address and
+// what remains of the index. The size is not inferred, since an index
+// may hold several addresses or other components after the address
+// (e.g., ipCidrRouteTable's dest.mask.tos.nexthop).
+func IndexToIP(suffix string, size int) (net.IP, string, error) {
+	if size != net.IPv4len && size != net.IPv6len {
+		return nil, suffix, errors.Errorf("invalid IP address size %d", size)
+	}
+	octets, rest, err := indexOctets(suffix, size)
+	if err != nil {
+		return nil, suffix, err
+	}
+	return net.IP(octets), rest, nil
+}
+
+// InetAddressType values for IPv4 and IPv6 addresses (INET-ADDRESS-MIB)
+const (
+	InetIPv4 = 1
+	InetIPv6 = 2
+)
+
+// IndexToInetAddress parses an InetAddressType and length prefixed
+// InetAddress pair at the start of a table index, as in ipAddressTable's
+// ipAddressAddrType.ipAddressAddr, returning the address and what
+// remains of the index
+func IndexToInetAddress(suffix string) (net.IP, string, error) {
+	prefix, rest, err := indexOctets(suffix, 2)
+	if err != nil {
+		return nil, suffix, err
+	}
+	var size int
+	switch prefix[0] {
+	case InetIPv4:
+		size = net.IPv4len
+	case InetIPv6:
+		size = net.IPv6len
+	default:
+		return nil, suffix, errors.Errorf("unsupported address type %d in index %s", prefix[0], suffix)
+	}
+	if int(prefix[1]) != size {
+		return nil, suffix, errors.Errorf("address length %d does not match type %d in index %s", prefix[1], prefix[0], suffix)
+	}
+	ip, rest, err := IndexToIP(rest, size)
+	if err != nil {
+		return nil, suffix, err
+	}
+	return ip, rest, nil
+}
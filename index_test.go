@@ -0,0 +1,69 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIndexToIP(t *testing.T) {
+	tests := []struct {
+		suffix string
+		size   int
+		ip     net.IP
+		rest   string
+	}{
+		{"10.1.2.3", net.IPv4len, net.ParseIP("10.1.2.3"), ""},
+		{"192.168.0.0.255.255.0.0.0.10.0.0.1", net.IPv4len, net.ParseIP("192.168.0.0"), "255.255.0.0.0.10.0.0.1"},
+		{"10.0.0.0.255.0.0.0.0.10.0.0.1.10.0.0.2.7", net.IPv4len, net.ParseIP("10.0.0.0"), "255.0.0.0.0.10.0.0.1.10.0.0.2.7"},
+		{"32.1.13.184.0.0.0.0.0.0.0.0.0.0.0.1", net.IPv6len, net.ParseIP("2001:db8::1"), ""},
+		{"32.1.13.184.0.0.0.0.0.0.0.0.0.0.0.1.7", net.IPv6len, net.ParseIP("2001:db8::1"), "7"},
+	}
+	for _, test := range tests {
+		ip, rest, err := IndexToIP(test.suffix, test.size)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !ip.Equal(test.ip) || rest != test.rest {
+			t.Errorf("index %s: expected %s/%q, got %s/%q", test.suffix, test.ip, test.rest, ip, rest)
+		}
+	}
+	for _, bad := range []string{"10.1.2", "10.1.2.300", "a.b.c.d"} {
+		if _, _, err := IndexToIP(bad, net.IPv4len); err == nil {
+			t.Errorf("expected error for index %s", bad)
+		}
+	}
+	if _, _, err := IndexToIP("10.1.2.3", 5); err == nil {
+		t.Error("expected error for address size 5")
+	}
+}
+
+func TestIndexToInetAddress(t *testing.T) {
+	tests := []struct {
+		suffix string
+		ip     net.IP
+		rest   string
+	}{
+		{"1.4.10.1.2.3", net.ParseIP("10.1.2.3"), ""},
+		{"2.16.32.1.13.184.0.0.0.0.0.0.0.0.0.0.0.1.5", net.ParseIP("2001:db8::1"), "5"},
+	}
+	for _, test := range tests {
+		ip, rest, err := IndexToInetAddress(test.suffix)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !ip.Equal(test.ip) || rest != test.rest {
+			t.Errorf("index %s: expected %s/%q, got %s/%q", test.suffix, test.ip, test.rest, ip, rest)
+		}
+	}
+	for _, bad := range []string{"1.16.10.1.2.3", "16.4.10.1.2.3", "1.4.10.1"} {
+		if _, _, err := IndexToInetAddress(bad); err == nil {
+			t.Errorf("expected error for index %s", bad)
+		}
+	}
+}
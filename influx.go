@@ -0,0 +1,124 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	measurementEscape = strings.NewReplacer(",", `\,`, " ", `\ `)
+	keyEscape         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	stringEscape      = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// influxValue renders a field value in line protocol form
+func influxValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case int:
+		return strconv.FormatInt(int64(v), 10) + "i", nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10) + "i", nil
+	case int64:
+		return strconv.FormatInt(v, 10) + "i", nil
+	case uint:
+		return influxUnsigned(uint64(v)), nil
+	case uint32:
+		return influxUnsigned(uint64(v)), nil
+	case uint64:
+		return influxUnsigned(v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case string:
+		return `"` + stringEscape.Replace(v) + `"`, nil
+	case []byte:
+		return `"` + stringEscape.Replace(cleanString(v)) + `"`, nil
+	case fmt.Stringer:
+		return `"` + stringEscape.Replace(v.String()) + `"`, nil
+	}
+	return "", errors.Errorf("unsupported field type:%T value:%v", value, value)
+}
+
+// influxUnsigned keeps counters as integers where they fit
+func influxUnsigned(v uint64) string {
+	if v > math.MaxInt64 {
+		return strconv.FormatUint(v, 10) + "u"
+	}
+	return strconv.FormatUint(v, 10) + "i"
+}
+
+// WriteInfluxLine writes the values (e.g., from Walk or Get) as a single
+// InfluxDB line protocol entry. Tags and fields are written in sorted order.
+func WriteInfluxLine(w io.Writer, measurement string, tags map[string]string, values map[string]interface{}, t time.Time) error {
+	if len(measurement) == 0 {
+		return errors.New("no measurement specified")
+	}
+	if len(values) == 0 {
+		return errors.Errorf("no fields for measurement %s", measurement)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(measurementEscape.Replace(measurement))
+	for _, k := range sortedKeys(tags) {
+		// empty tag values are not valid line protocol
+		if len(tags[k]) == 0 {
+			continue
+		}
+		b.WriteByte(',')
+		b.WriteString(keyEscape.Replace(k))
+		b.WriteByte('=')
+		b.WriteString(keyEscape.Replace(tags[k]))
+	}
+
+	names := make([]string, 0, len(values))
+	for k := range values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for i, k := range names {
+		v, err := influxValue(values[k])
+		if err != nil {
+			return errors.Wrapf(err, "field %s", k)
+		}
+		if i == 0 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteByte(',')
+		}
+		b.WriteString(keyEscape.Replace(k))
+		b.WriteByte('=')
+		b.WriteString(v)
+	}
+	if !t.IsZero() {
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatInt(t.UnixNano(), 10))
+	}
+	b.WriteByte('\n')
+	_, err := w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,34 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWriteInfluxLine(t *testing.T) {
+	var b bytes.Buffer
+	tags := map[string]string{"host": "router 1", "if,name": "ge-0/0/1", "empty": ""}
+	values := map[string]interface{}{
+		"descr":  `say "hi"`,
+		"octets": uint64(1234),
+		"rate":   1.5,
+		"status": 1,
+	}
+	when := time.Unix(1, 5)
+	if err := WriteInfluxLine(&b, "if stats", tags, values, when); err != nil {
+		t.Fatal(err)
+	}
+	expected := `if\ stats,host=router\ 1,if\,name=ge-0/0/1 descr="say \"hi\"",octets=1234i,rate=1.5,status=1i 1000000005` + "\n"
+	if b.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+
+	if err := WriteInfluxLine(&b, "empty", nil, nil, when); err == nil {
+		t.Error("expected error for empty fields")
+	}
+}
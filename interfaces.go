@@ -0,0 +1,340 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+const (
+	ifType        = ".1.3.6.1.2.1.2.2.1.3"
+	ifSpeed       = ".1.3.6.1.2.1.2.2.1.5"
+	ifAdminStatus = ".1.3.6.1.2.1.2.2.1.7"
+	ifHighSpeed   = ".1.3.6.1.2.1.31.1.1.1.15"
+	ifInOctets    = ".1.3.6.1.2.1.2.2.1.10"
+	ifOutOctets   = ".1.3.6.1.2.1.2.2.1.16"
+	ifHCInOctets  = ".1.3.6.1.2.1.31.1.1.1.6"
+	ifHCOutOctets = ".1.3.6.1.2.1.31.1.1.1.10"
+
+	ifInUcastPkts    = ".1.3.6.1.2.1.2.2.1.11"
+	ifInDiscards     = ".1.3.6.1.2.1.2.2.1.13"
+	ifInErrors       = ".1.3.6.1.2.1.2.2.1.14"
+	ifOutUcastPkts   = ".1.3.6.1.2.1.2.2.1.17"
+	ifOutDiscards    = ".1.3.6.1.2.1.2.2.1.19"
+	ifOutErrors      = ".1.3.6.1.2.1.2.2.1.20"
+	ifHCInUcastPkts  = ".1.3.6.1.2.1.31.1.1.1.7"
+	ifHCOutUcastPkts = ".1.3.6.1.2.1.31.1.1.1.11"
+)
+
+// ifTypes are the common IANAifType names
+var ifTypes = map[int]string{
+	1:   "other",
+	6:   "ethernetCsmacd",
+	18:  "ds1",
+	22:  "propPointToPointSerial",
+	23:  "ppp",
+	24:  "softwareLoopback",
+	30:  "ds3",
+	32:  "frameRelay",
+	37:  "atm",
+	53:  "propVirtual",
+	62:  "fastEther",
+	71:  "ieee80211",
+	117: "gigabitEthernet",
+	131: "tunnel",
+	135: "l2vlan",
+	136: "l3ipvlan",
+	150: "mplsTunnel",
+	161: "ieee8023adLag",
+	166: "mpls",
+	209: "bridge",
+}
+
+// IfTypeName returns the IANAifType name of an ifType, or its number if unknown
+func IfTypeName(t int) string {
+	if name, ok := ifTypes[t]; ok {
+		return name
+	}
+	return strconv.Itoa(t)
+}
+
+// IfState is the administrative or operational state of an interface
+type IfState int
+
+// Interface states as defined by IF-MIB
+const (
+	IfUp IfState = iota + 1
+	IfDown
+	IfTesting
+	IfUnknown
+	IfDormant
+	IfNotPresent
+	IfLowerLayerDown
+)
+
+var ifStates = map[IfState]string{
+	IfUp:             "up",
+	IfDown:           "down",
+	IfTesting:        "testing",
+	IfUnknown:        "unknown",
+	IfDormant:        "dormant",
+	IfNotPresent:     "notPresent",
+	IfLowerLayerDown: "lowerLayerDown",
+}
+
+func (s IfState) String() string {
+	if name, ok := ifStates[s]; ok {
+		return name
+	}
+	return strconv.Itoa(int(s))
+}
+
+// IfStatus is the combined admin and operational status of an interface
+type IfStatus struct {
+	Admin, Oper IfState
+}
+
+// ifIndex returns the interface index from the OID of an ifTable column
+func ifIndex(column, oid string) (int, error) {
+	if len(oid) <= len(column)+1 {
+		return 0, errors.Errorf("no index for %s", oid)
+	}
+	return strconv.Atoi(oid[len(column)+1:])
+}
+
+// ifColumn walks an integer column of the ifTable, applying fn to each value by index
+func ifColumn(client *gosnmp.GoSNMP, column string, fn func(int, int)) error {
+	return walkFunc(client)(column, func(pdu gosnmp.SnmpPDU) error {
+		index, err := ifIndex(column, pdu.Name)
+		if err != nil {
+			return err
+		}
+		v, ok := pdu.Value.(int)
+		if !ok {
+			return errors.Errorf("invalid type for %s: %T", pdu.Name, pdu.Value)
+		}
+		fn(index, v)
+		return nil
+	})
+}
+
+// InterfaceStatus returns the admin and operational status of each interface by ifIndex
+func InterfaceStatus(client *gosnmp.GoSNMP) (map[int]IfStatus, error) {
+	status := make(map[int]IfStatus)
+	err := ifColumn(client, ifAdminStatus, func(index, v int) {
+		s := status[index]
+		s.Admin = IfState(v)
+		status[index] = s
+	})
+	if err != nil {
+		return status, err
+	}
+	err = ifColumn(client, ifOperStatus, func(index, v int) {
+		s := status[index]
+		s.Oper = IfState(v)
+		status[index] = s
+	})
+	return status, err
+}
+
+// Interface describes an interface of the ifTable and ifXTable
+type Interface struct {
+	Index       int
+	Name, Descr string
+	Type        int
+	TypeName    string // IANAifType name, e.g., "ethernetCsmacd"
+	Speed       uint64 // bits per second
+	Admin, Oper IfState
+}
+
+// ifStrings walks an octet string column, applying fn to each value by index
+func ifStrings(client *gosnmp.GoSNMP, column string, fn func(int, string)) error {
+	return walkFunc(client)(column, func(pdu gosnmp.SnmpPDU) error {
+		index, err := ifIndex(column, pdu.Name)
+		if err != nil {
+			return err
+		}
+		b, _ := pdu.Value.([]byte)
+		fn(index, cleanString(b))
+		return nil
+	})
+}
+
+// ifNumbers walks an integer or gauge column, applying fn to each value by index
+func ifNumbers(client *gosnmp.GoSNMP, column string, fn func(int, uint64)) error {
+	return walkFunc(client)(column, func(pdu gosnmp.SnmpPDU) error {
+		index, err := ifIndex(column, pdu.Name)
+		if err != nil {
+			return err
+		}
+		fn(index, gosnmp.ToBigInt(pdu.Value).Uint64())
+		return nil
+	})
+}
+
+// Interfaces returns the device's interfaces ordered by ifIndex, joining
+// the ifTable and ifXTable columns most often wanted. The speed is taken
+// from ifHighSpeed where ifSpeed is saturated (links over 4Gb/s) or missing.
+func Interfaces(client *gosnmp.GoSNMP) ([]Interface, error) {
+	ifaces := make(map[int]*Interface)
+	row := func(index int) *Interface {
+		i, ok := ifaces[index]
+		if !ok {
+			i = &Interface{Index: index}
+			ifaces[index] = i
+		}
+		return i
+	}
+	highSpeed := make(map[int]uint64)
+	walks := []func() error{
+		func() error { return ifStrings(client, ifDescr, func(i int, s string) { row(i).Descr = s }) },
+		func() error { return ifStrings(client, ifName, func(i int, s string) { row(i).Name = s }) },
+		func() error {
+			return ifNumbers(client, ifType, func(i int, v uint64) {
+				row(i).Type = int(v)
+				row(i).TypeName = IfTypeName(int(v))
+			})
+		},
+		func() error { return ifNumbers(client, ifSpeed, func(i int, v uint64) { row(i).Speed = v }) },
+		func() error { return ifNumbers(client, ifHighSpeed, func(i int, v uint64) { highSpeed[i] = v }) },
+		func() error {
+			return ifNumbers(client, ifAdminStatus, func(i int, v uint64) { row(i).Admin = IfState(v) })
+		},
+		func() error {
+			return ifNumbers(client, ifOperStatus, func(i int, v uint64) { row(i).Oper = IfState(v) })
+		},
+	}
+	for _, walk := range walks {
+		if err := walk(); err != nil {
+			return nil, err
+		}
+	}
+	return joinInterfaces(ifaces, highSpeed), nil
+}
+
+// joinInterfaces applies the high speed values and orders the interfaces
+func joinInterfaces(ifaces map[int]*Interface, highSpeed map[int]uint64) []Interface {
+	list := make([]Interface, 0, len(ifaces))
+	for index, i := range ifaces {
+		// ifHighSpeed is rounded to Mb/s, so only for links ifSpeed can't
+		// hold, or whose ifSpeed is missing
+		if hs, ok := highSpeed[index]; ok && hs > 0 && (i.Speed == math.MaxUint32 || i.Speed == 0) {
+			i.Speed = hs * 1000000
+		}
+		if len(i.Name) == 0 {
+			// ifXTable is optional
+			i.Name = i.Descr
+		}
+		list = append(list, *i)
+	}
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].Index < list[b].Index
+	})
+	return list
+}
+
+// IfCounters are the traffic counters of an interface. The octet and
+// packet counters are 64 bit if HighCapacity, the others always 32 bit.
+type IfCounters struct {
+	IfIndex                 int
+	InOctets, OutOctets     uint64
+	InPkts, OutPkts         uint64 // unicast
+	InErrors, OutErrors     uint64
+	InDiscards, OutDiscards uint64
+	HighCapacity            bool // 64 bit counters from the ifXTable
+}
+
+// HasHighCapacityCounters returns whether the agent supports the
+// ifXTable's 64 bit counters, probing for the first interface's ifHCInOctets.
+// The result is cached if a capability cache is set (see SetCapabilityCache).
+func HasHighCapacityCounters(client *gosnmp.GoSNMP) (bool, error) {
+	return cachedCapability(client, CapHighCapacity, func() (bool, error) {
+		return hasHighCapacityCounters(client)
+	})
+}
+
+func hasHighCapacityCounters(client *gosnmp.GoSNMP) (bool, error) {
+	if client.Version == gosnmp.Version1 {
+		// Counter64 is not defined for v1
+		return false, nil
+	}
+	if err := connected(client); err != nil {
+		return false, err
+	}
+	packet, err := client.GetNext([]string{ifHCInOctets})
+	if err == nil {
+		err = packetError(client, packet)
+	}
+	recordStats(client, err)
+	if err != nil {
+		return false, err
+	}
+	if packet.Error != gosnmp.NoError || len(packet.Variables) != 1 {
+		return false, nil
+	}
+	pdu := packet.Variables[0]
+	return pdu.Type == gosnmp.Counter64 && strings.HasPrefix(pdu.Name, ifHCInOctets+"."), nil
+}
+
+// InterfaceStats returns the traffic counters of each interface ordered
+// by ifIndex, using the 64 bit counters if the agent has them to avoid
+// wrapping on fast links
+func InterfaceStats(client *gosnmp.GoSNMP) ([]IfCounters, error) {
+	hc, err := HasHighCapacityCounters(client)
+	if err != nil {
+		return nil, err
+	}
+	inOctets, outOctets, inPkts, outPkts := ifInOctets, ifOutOctets, ifInUcastPkts, ifOutUcastPkts
+	if hc {
+		inOctets, outOctets, inPkts, outPkts = ifHCInOctets, ifHCOutOctets, ifHCInUcastPkts, ifHCOutUcastPkts
+	}
+	rows := make(map[int]*IfCounters)
+	row := func(index int) *IfCounters {
+		r, ok := rows[index]
+		if !ok {
+			r = &IfCounters{IfIndex: index, HighCapacity: hc}
+			rows[index] = r
+		}
+		return r
+	}
+	walks := []struct {
+		column string
+		set    func(*IfCounters, uint64)
+	}{
+		{inOctets, func(r *IfCounters, v uint64) { r.InOctets = v }},
+		{outOctets, func(r *IfCounters, v uint64) { r.OutOctets = v }},
+		{inPkts, func(r *IfCounters, v uint64) { r.InPkts = v }},
+		{outPkts, func(r *IfCounters, v uint64) { r.OutPkts = v }},
+		{ifInErrors, func(r *IfCounters, v uint64) { r.InErrors = v }},
+		{ifOutErrors, func(r *IfCounters, v uint64) { r.OutErrors = v }},
+		{ifInDiscards, func(r *IfCounters, v uint64) { r.InDiscards = v }},
+		{ifOutDiscards, func(r *IfCounters, v uint64) { r.OutDiscards = v }},
+	}
+	for _, w := range walks {
+		set := w.set
+		if err := ifNumbers(client, w.column, func(i int, v uint64) { set(row(i), v) }); err != nil {
+			return nil, err
+		}
+	}
+	return sortCounters(rows), nil
+}
+
+// sortCounters orders the counters by ifIndex
+func sortCounters(rows map[int]*IfCounters) []IfCounters {
+	counters := make([]IfCounters, 0, len(rows))
+	for _, r := range rows {
+		counters = append(counters, *r)
+	}
+	sort.Slice(counters, func(a, b int) bool {
+		return counters[a].IfIndex < counters[b].IfIndex
+	})
+	return counters
+}
@@ -0,0 +1,115 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestJoinInterfaces(t *testing.T) {
+	ifaces := map[int]*Interface{
+		2: {Index: 2, Descr: "eth0", Name: "eth0", Type: 6, TypeName: IfTypeName(6), Speed: math.MaxUint32, Admin: IfUp, Oper: IfUp},
+		1: {Index: 1, Descr: "lo", Type: 24, TypeName: IfTypeName(24), Speed: 10000000},
+		// a link slower than 1Mb/s, and one with no ifSpeed
+		3: {Index: 3, Descr: "ser0", Speed: 1500000},
+		4: {Index: 4, Descr: "ser1"},
+	}
+	list := joinInterfaces(ifaces, map[int]uint64{1: 10, 2: 10000, 3: 2, 4: 2})
+	if len(list) != 4 {
+		t.Fatalf("expected 4 interfaces, got %d", len(list))
+	}
+	if list[2].Speed != 1500000 {
+		t.Errorf("expected ifSpeed to be kept over a rounded ifHighSpeed, got %d", list[2].Speed)
+	}
+	if list[3].Speed != 2000000 {
+		t.Errorf("expected high speed for missing ifSpeed, got %d", list[3].Speed)
+	}
+	if i := list[0]; i.Index != 1 || i.Name != "lo" || i.TypeName != "softwareLoopback" || i.Speed != 10000000 {
+		t.Errorf("unexpected interface: %+v", i)
+	}
+	if i := list[1]; i.Speed != 10000000000 || i.TypeName != "ethernetCsmacd" || i.Oper != IfUp {
+		t.Errorf("expected high speed for saturated ifSpeed: %+v", i)
+	}
+	if name := IfTypeName(9999); name != "9999" {
+		t.Errorf("expected number for unknown type, got %s", name)
+	}
+}
+
+func TestHasHighCapacityCounters(t *testing.T) {
+	for _, tc := range []struct {
+		next Record
+		hc   bool
+	}{
+		{Record{OID: ifHCInOctets + ".3", Type: int32(gosnmp.Counter64), IntVal: 1234}, true},
+		// no ifXTable, so the next OID is past the column
+		{Record{OID: ifHCOutOctets + ".1", Type: int32(gosnmp.Counter64), IntVal: 1}, false},
+	} {
+		client := replayAgent(t, Exchange{
+			PDUType:  gosnmp.GetNextRequest,
+			OIDs:     []string{ifHCInOctets},
+			Response: []Record{tc.next},
+		})
+		hc, err := HasHighCapacityCounters(client)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hc != tc.hc {
+			t.Errorf("expected %t for next OID %s, got %t", tc.hc, tc.next.OID, hc)
+		}
+	}
+}
+
+func TestInterfaceStats(t *testing.T) {
+	exchanges := []Exchange{{
+		PDUType: gosnmp.GetNextRequest,
+		OIDs:    []string{ifHCInOctets},
+		// no ifXTable
+		Response: []Record{{OID: ".1.3.6.1.2.1.31.2.1.0", Type: int32(gosnmp.Integer), IntVal: 1}},
+	}}
+	for _, col := range []struct {
+		oid      string
+		one, two int64
+	}{
+		{ifInOctets, 100, 200},
+		{ifOutOctets, 50, 75},
+		{ifInUcastPkts, 1, 2},
+		{ifOutUcastPkts, 3, 4},
+		{ifInErrors, 0, 5},
+		{ifOutErrors, 0, 0},
+		{ifInDiscards, 6, 0},
+		{ifOutDiscards, 0, 0},
+	} {
+		exchanges = append(exchanges, Exchange{
+			PDUType:        gosnmp.GetBulkRequest,
+			OIDs:           []string{col.oid},
+			MaxRepetitions: defaultRepetitions,
+			Response: []Record{
+				{OID: col.oid + ".1", Type: int32(gosnmp.Counter32), IntVal: col.one},
+				{OID: col.oid + ".2", Type: int32(gosnmp.Counter32), IntVal: col.two},
+				{OID: ".1.3.6.1.2.1.2.3.0", Type: int32(gosnmp.Integer), IntVal: 1},
+			},
+		})
+	}
+	client := replayAgent(t, exchanges...)
+	counters, err := InterfaceStats(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counters) != 2 {
+		t.Fatalf("expected 2 interfaces, got %d", len(counters))
+	}
+	if c := counters[0]; c.IfIndex != 1 || c.InOctets != 100 || c.OutOctets != 50 || c.HighCapacity {
+		t.Errorf("unexpected counters: %+v", c)
+	}
+	if c := counters[1]; c.IfIndex != 2 || c.InOctets != 200 || c.OutOctets != 75 || c.OutPkts != 4 || c.InErrors != 5 {
+		t.Errorf("unexpected counters: %+v", c)
+	}
+	if c := counters[0]; c.InPkts != 1 || c.InDiscards != 6 {
+		t.Errorf("unexpected counters: %+v", c)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// MeasureLatency issues samples sysUpTime GETs back to back and returns the
+// minimum, maximum and average round trip. It stops at the first failure.
+func MeasureLatency(client *gosnmp.GoSNMP, samples int) (min, max, avg time.Duration, err error) {
+	if samples < 1 {
+		return 0, 0, 0, errors.Errorf("invalid sample count: %d", samples)
+	}
+	if err := connected(client); err != nil {
+		return 0, 0, 0, err
+	}
+	var total time.Duration
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		packet, err := client.Get([]string{sysUpTime})
+		elapsed := time.Since(start)
+		if err == nil {
+			err = packetError(client, packet)
+		}
+		recordStats(client, err)
+		if err != nil {
+			return min, max, avg, errors.Wrapf(err, "latency sample %d", i+1)
+		}
+		if i == 0 || elapsed < min {
+			min = elapsed
+		}
+		if elapsed > max {
+			max = elapsed
+		}
+		total += elapsed
+	}
+	return min, max, total / time.Duration(samples), nil
+}
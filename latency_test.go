@@ -0,0 +1,37 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestMeasureLatency(t *testing.T) {
+	agent := replayer(t, Exchange{
+		PDUType:  gosnmp.GetRequest,
+		OIDs:     []string{sysUpTime},
+		Response: []Record{{OID: sysUpTime, Type: int32(gosnmp.TimeTicks), IntVal: 100}},
+	})
+	client := replayClient(t, agent)
+	defer client.Conn.Close()
+
+	min, max, avg, err := MeasureLatency(client, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if min <= 0 || min > avg || avg > max {
+		t.Errorf("inconsistent latency: min %s avg %s max %s", min, avg, max)
+	}
+	if _, _, _, err := MeasureLatency(client, 0); err == nil {
+		t.Error("expected error for no samples")
+	}
+
+	agent.Close()
+	if _, _, _, err := MeasureLatency(client, 3); err == nil {
+		t.Error("expected error once the agent is gone")
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"context"
+	"sync"
+)
+
+// TargetLimiter caps the number of in-flight requests to each host.
+// Share one limiter across sessions to apply the cap process wide.
+type TargetLimiter struct {
+	sync.Mutex
+	limit int
+	hosts map[string]chan struct{}
+}
+
+// NewTargetLimiter returns a limiter allowing n concurrent requests per host
+func NewTargetLimiter(n int) *TargetLimiter {
+	if n < 1 {
+		n = 1
+	}
+	return &TargetLimiter{limit: n, hosts: make(map[string]chan struct{})}
+}
+
+// Acquire waits for a request slot for host, returning a func to release it.
+// An error is returned if ctx ends before a slot is available.
+func (l *TargetLimiter) Acquire(ctx context.Context, host string) (func(), error) {
+	l.Lock()
+	sem, ok := l.hosts[host]
+	if !ok {
+		sem = make(chan struct{}, l.limit)
+		l.hosts[host] = sem
+	}
+	l.Unlock()
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTargetLimiter(t *testing.T) {
+	l := NewTargetLimiter(1)
+	release, err := l.Acquire(context.Background(), testHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, testHost); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	other, err := l.Acquire(context.Background(), "otherhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other()
+
+	release()
+	release, err = l.Acquire(context.Background(), testHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
@@ -0,0 +1,180 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+const (
+	lldpLocPortTable = ".1.0.8802.1.1.2.1.3.7"
+	lldpRemTable     = ".1.0.8802.1.1.2.1.4.1"
+)
+
+// lldpLocPortEntry columns
+const (
+	lldpLocPortIDSubtype = 2
+	lldpLocPortID        = 3
+)
+
+// lldpRemEntry columns
+const (
+	lldpRemChassisIDSubtype = 4
+	lldpRemChassisID        = 5
+	lldpRemPortIDSubtype    = 6
+	lldpRemPortID           = 7
+	lldpRemPortDesc         = 8
+	lldpRemSysName          = 9
+)
+
+// LldpChassisIdSubtype and LldpPortIdSubtype values that need decoding
+const (
+	chassisMACAddress     = 4
+	chassisNetworkAddress = 5
+	portInterfaceAlias    = 1
+	portMACAddress        = 3
+	portNetworkAddress    = 4
+	portInterfaceName     = 5
+)
+
+// LLDPNeighbor is a device seen on a local port by LLDP
+type LLDPNeighbor struct {
+	LocalPort        int // lldpLocalPortNum
+	IfIndex          int // the local port's interface
+	ChassisIDSubtype int
+	ChassisID        string // MAC and network addresses are decoded
+	PortIDSubtype    int
+	PortID           string
+	PortDesc         string
+	SysName          string
+}
+
+// octets decodes octet strings as raw bytes for subtype specific decoding
+func octets(pdu gosnmp.SnmpPDU) (interface{}, error) {
+	if pdu.Type == gosnmp.OctetString {
+		if b, ok := pdu.Value.([]byte); ok {
+			return b, nil
+		}
+	}
+	return pduType(pdu)
+}
+
+// LLDPNeighbors returns the neighbors in the lldpRemTable, with each
+// local port resolved to an ifIndex. Ports identified by interface name
+// or alias are matched to the ifXTable; otherwise the port number is
+// taken to be the ifIndex, as is the common practice.
+func LLDPNeighbors(client *gosnmp.GoSNMP) ([]LLDPNeighbor, error) {
+	rows, err := getTable(client, lldpRemTable, octets)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	ports, err := getTable(client, lldpLocPortTable, octets)
+	if err != nil {
+		return nil, err
+	}
+	names, err := Walk(client, ifName)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := Walk(client, ifAlias)
+	if err != nil {
+		return nil, err
+	}
+	return lldpNeighbors(rows, lldpIfIndexes(ports, names, aliases))
+}
+
+// lldpIfIndexes maps local port numbers to ifIndexes by matching the
+// port IDs that are interface names or aliases
+func lldpIfIndexes(ports map[string]map[int]interface{}, names, aliases map[string]interface{}) map[int]int {
+	byValue := func(col string, values map[string]interface{}) map[string]int {
+		m := make(map[string]int, len(values))
+		for oid, v := range values {
+			index, err := strconv.Atoi(strings.TrimPrefix(oid, col+"."))
+			if s, ok := v.(string); ok && err == nil && len(s) > 0 {
+				m[s] = index
+			}
+		}
+		return m
+	}
+	byName, byAlias := byValue(ifName, names), byValue(ifAlias, aliases)
+	indexes := make(map[int]int, len(ports))
+	for index, row := range ports {
+		port, err := strconv.Atoi(index)
+		if err != nil {
+			continue
+		}
+		id, _ := row[lldpLocPortID].([]byte)
+		subtype, _ := row[lldpLocPortIDSubtype].(int)
+		switch subtype {
+		case portInterfaceName:
+			if i, ok := byName[string(id)]; ok {
+				indexes[port] = i
+			}
+		case portInterfaceAlias:
+			if i, ok := byAlias[string(id)]; ok {
+				indexes[port] = i
+			}
+		}
+	}
+	return indexes
+}
+
+// lldpID decodes a chassis or port ID according to its subtype
+func lldpID(b []byte, mac, network bool) string {
+	switch {
+	case mac && len(b) == 6:
+		return net.HardwareAddr(b).String()
+	case network && len(b) > 1:
+		// an IANA address family followed by the address
+		switch {
+		case b[0] == 1 && len(b) == 1+net.IPv4len, b[0] == 2 && len(b) == 1+net.IPv6len:
+			return net.IP(b[1:]).String()
+		}
+	}
+	if isPrintable(b) {
+		return string(b)
+	}
+	return hexOctets(b)
+}
+
+func lldpNeighbors(rows map[string]map[int]interface{}, ifIndexes map[int]int) ([]LLDPNeighbor, error) {
+	neighbors := make([]LLDPNeighbor, 0, len(rows))
+	for _, index := range sortedIndexes(rows) {
+		// timemark.localPortNum.index
+		parts := strings.Split(index, ".")
+		if len(parts) != 3 {
+			return nil, errors.Errorf("invalid lldpRemTable index: %s", index)
+		}
+		port, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, errors.Errorf("invalid lldpRemTable index: %s", index)
+		}
+		row := rows[index]
+		n := LLDPNeighbor{LocalPort: port, IfIndex: port}
+		if i, ok := ifIndexes[port]; ok {
+			n.IfIndex = i
+		}
+		n.ChassisIDSubtype, _ = row[lldpRemChassisIDSubtype].(int)
+		n.PortIDSubtype, _ = row[lldpRemPortIDSubtype].(int)
+		chassis, _ := row[lldpRemChassisID].([]byte)
+		n.ChassisID = lldpID(chassis, n.ChassisIDSubtype == chassisMACAddress, n.ChassisIDSubtype == chassisNetworkAddress)
+		id, _ := row[lldpRemPortID].([]byte)
+		n.PortID = lldpID(id, n.PortIDSubtype == portMACAddress, n.PortIDSubtype == portNetworkAddress)
+		desc, _ := row[lldpRemPortDesc].([]byte)
+		n.PortDesc = cleanString(desc)
+		name, _ := row[lldpRemSysName].([]byte)
+		n.SysName = cleanString(name)
+		neighbors = append(neighbors, n)
+	}
+	return neighbors, nil
+}
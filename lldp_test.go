@@ -0,0 +1,53 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+)
+
+func TestLLDPNeighbors(t *testing.T) {
+	ports := map[string]map[int]interface{}{
+		"1": {lldpLocPortIDSubtype: portInterfaceName, lldpLocPortID: []byte("Gi0/1")},
+		"2": {lldpLocPortIDSubtype: portMACAddress, lldpLocPortID: []byte{0, 1, 2, 3, 4, 5}},
+	}
+	names := map[string]interface{}{ifName + ".10101": "Gi0/1"}
+	indexes := lldpIfIndexes(ports, names, nil)
+
+	rows := map[string]map[int]interface{}{
+		"0.1.3": {
+			lldpRemChassisIDSubtype: chassisMACAddress,
+			lldpRemChassisID:        []byte{0, 0x1b, 0x21, 1, 2, 3},
+			lldpRemPortIDSubtype:    portInterfaceName,
+			lldpRemPortID:           []byte("Ethernet1/1"),
+			lldpRemSysName:          []byte("core1"),
+		},
+		"0.2.1": {
+			lldpRemChassisIDSubtype: chassisNetworkAddress,
+			lldpRemChassisID:        []byte{1, 10, 0, 0, 1},
+			lldpRemPortIDSubtype:    portMACAddress,
+			lldpRemPortID:           []byte{0, 0x50, 0x56, 0xab, 0xcd, 0xef},
+		},
+	}
+	neighbors, err := lldpNeighbors(rows, indexes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(neighbors) != 2 {
+		t.Fatalf("expected 2 neighbors, got %d", len(neighbors))
+	}
+	n := neighbors[0]
+	if n.LocalPort != 1 || n.IfIndex != 10101 || n.ChassisID != "00:1b:21:01:02:03" || n.PortID != "Ethernet1/1" || n.SysName != "core1" {
+		t.Errorf("unexpected neighbor: %+v", n)
+	}
+	n = neighbors[1]
+	if n.LocalPort != 2 || n.IfIndex != 2 || n.ChassisID != "10.0.0.1" || n.PortID != "00:50:56:ab:cd:ef" {
+		t.Errorf("unexpected neighbor: %+v", n)
+	}
+
+	if _, err := lldpNeighbors(map[string]map[int]interface{}{"0.1": {}}, nil); err == nil {
+		t.Error("expected error for short index")
+	}
+}
@@ -39,9 +39,11 @@ type MibInfo struct {
 }
 
 type oidInfo struct {
-	Name  string
-	Index int
-	Fn    pduReader
+	Name        string
+	Index       int
+	Fn          pduReader
+	DateAndTime bool
+	Enums       map[int]string // labels of an enumerated INTEGER
 }
 
 type mibFunc func(MibInfo)
@@ -103,14 +105,26 @@ func oidReader(m MibInfo) {
 		lookupOID[name] = oid
 	}
 	mu.Unlock()
-	oidBase[oid] = oidInfo{Name: m.Name, Index: index, Fn: pduFunc(m)}
+	info := oidInfo{Name: m.Name, Index: index, Fn: pduFunc(m), DateAndTime: isDateAndTime(m)}
+	if kind, enums := looker(m.Syntax); kind == "INTEGER" && len(enums) > 0 {
+		info.Enums = enums
+	}
+	oidBase[oid] = info
 	rtree, _, _ = rtree.Insert([]byte(oid), name)
 }
 
+// oidName returns the symbolic name of an OID, if known
+func oidName(oid string) (string, bool) {
+	if v, ok := rtree.Root().Get([]byte(oid)); ok {
+		return v.(string), true
+	}
+	return oid, false
+}
+
 // pduFunc returns a pduReader based upon the OID type and hints
 // TODO: add other hinted formats functions here
 func pduFunc(m MibInfo) pduReader {
-	if m.Hint == "2d-1d-1d,1d:1d:1d.1d,1a1d:1d" {
+	if isDateAndTime(m) {
 		return dateTime
 	}
 	if fn := numberType(m.Syntax); fn != nil {
@@ -119,6 +133,14 @@ func pduFunc(m MibInfo) pduReader {
 	return pduType
 }
 
+// dateAndTimeHint is the DISPLAY-HINT of the DateAndTime textual convention
+const dateAndTimeHint = "2d-1d-1d,1d:1d:1d.1d,1a1d:1d"
+
+// isDateAndTime reports whether the object is declared as a DateAndTime
+func isDateAndTime(m MibInfo) bool {
+	return m.Hint == dateAndTimeHint || m.Syntax == "DateAndTime"
+}
+
 // mibFile decodes a stream
 func mibFile(r io.Reader, fn mibFunc) error {
 	dec := json.NewDecoder(r)
@@ -361,7 +383,10 @@ func numberType(s string) pduReader {
 
 func bitFormatter(m map[int]string) pduReader {
 	return func(pdu gosnmp.SnmpPDU) (interface{}, error) {
-		data := pdu.Value.([]byte)
+		data, ok := pdu.Value.([]byte)
+		if !ok {
+			return pdu.Value, typeMismatch(pdu, "octets")
+		}
 		names := make([]string, 0, len(data)*8)
 		cnt := 0
 		for _, d := range data {
@@ -383,7 +408,10 @@ func bitFormatter(m map[int]string) pduReader {
 
 func intFormatter(m map[int]string) pduReader {
 	return func(pdu gosnmp.SnmpPDU) (interface{}, error) {
-		v := pdu.Value.(int)
+		v, ok := pdu.Value.(int)
+		if !ok {
+			return pdu.Value, typeMismatch(pdu, "an integer")
+		}
 		if name, ok := m[v]; ok {
 			return name, nil
 		}
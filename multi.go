@@ -0,0 +1,256 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// ErrMultiTargetClosed is returned for requests made after Close
+var ErrMultiTargetClosed = errors.New("multi target socket is closed")
+
+// TargetResponse is the result of a GET to one target of a MultiTarget
+type TargetResponse struct {
+	Target string
+	Source *net.UDPAddr
+	Values map[string]interface{}
+	Err    error
+}
+
+// MultiTarget sends GETs to any number of agents over a single unconnected
+// UDP socket, rather than a socket per client. Responses are matched to
+// requests by request ID and source address. Only SNMP v1 and v2c are supported.
+//
+// gosnmp clients are bound to one target, so requests are encoded and
+// decoded here using gosnmp's packet functions.
+type MultiTarget struct {
+	sync.Mutex
+	conn      net.PacketConn
+	version   gosnmp.SnmpVersion
+	community string
+	timeout   time.Duration
+	retries   int
+	port      int
+	decoder   *gosnmp.GoSNMP
+	pending   map[uint32]chan *multiReply
+	requestID uint32
+	closed    bool
+	done      chan struct{}
+}
+
+type multiReply struct {
+	packet *gosnmp.SnmpPacket
+	source *net.UDPAddr
+}
+
+// NewMultiTarget opens a socket for polling agents sharing the profile's
+// version, community, port, timeout and retries. The profile's host is not used.
+func NewMultiTarget(p Profile) (*MultiTarget, error) {
+	var version gosnmp.SnmpVersion
+	switch p.Version {
+	case "1":
+		version = gosnmp.Version1
+	case "", "2", "2c":
+		version = gosnmp.Version2c
+	default:
+		return nil, errors.Errorf("unsupported snmp version for multi target: %s", p.Version)
+	}
+	if p.Port == 0 {
+		p.Port = defaultPort
+	}
+	retries, _ := clampRetries(p.Retries)
+	conn, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		return nil, errors.Wrap(err, "multi target socket")
+	}
+	m := &MultiTarget{
+		conn:      conn,
+		version:   version,
+		community: p.Community,
+		timeout:   time.Duration(p.Timeout) * time.Second,
+		retries:   retries,
+		port:      p.Port,
+		decoder:   &gosnmp.GoSNMP{Version: version, Community: p.Community},
+		pending:   make(map[uint32]chan *multiReply),
+		requestID: rand.Uint32(),
+		done:      make(chan struct{}),
+	}
+	if m.timeout <= 0 {
+		m.timeout = gosnmp.Default.Timeout
+	}
+	go m.receive()
+	return m, nil
+}
+
+// Close closes the socket, failing any requests in flight
+func (m *MultiTarget) Close() error {
+	m.Lock()
+	m.closed = true
+	m.Unlock()
+	err := m.conn.Close()
+	<-m.done
+	return err
+}
+
+// receive dispatches responses to the requests waiting on them
+func (m *MultiTarget) receive() {
+	defer close(m.done)
+	buf := make([]byte, maxTrapSize)
+	for {
+		n, addr, err := m.conn.ReadFrom(buf)
+		if err != nil {
+			m.Lock()
+			for id, ch := range m.pending {
+				close(ch)
+				delete(m.pending, id)
+			}
+			m.Unlock()
+			return
+		}
+		// the reply outlives buf, which its octet strings point into
+		packet, err := m.decoder.SnmpDecodePacket(copyBytes(buf[:n]))
+		if err != nil {
+			continue
+		}
+		m.Lock()
+		ch, ok := m.pending[packet.RequestID]
+		if ok {
+			delete(m.pending, packet.RequestID)
+		}
+		m.Unlock()
+		if ok {
+			ch <- &multiReply{packet: packet, source: addr.(*net.UDPAddr)}
+		}
+	}
+}
+
+// resolve returns the UDP address of target, using the default port if none is given
+func (m *MultiTarget) resolve(target string) (*net.UDPAddr, error) {
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		target = net.JoinHostPort(target, strconv.Itoa(m.port))
+	}
+	addr, err := net.ResolveUDPAddr("udp", target)
+	return addr, errors.Wrapf(err, "resolve %s", target)
+}
+
+// Get returns the values of the given OIDs from target, which may
+// include a port. It is safe to call from multiple goroutines.
+func (m *MultiTarget) Get(target string, oids []string) TargetResponse {
+	resp := TargetResponse{Target: target}
+	addr, err := m.resolve(target)
+	if err != nil {
+		resp.Err = err
+		return resp
+	}
+	vars := make([]gosnmp.SnmpPDU, 0, len(oids))
+	for _, oid := range oids {
+		oid, err := getOID(oid)
+		if err != nil {
+			resp.Err = err
+			return resp
+		}
+		vars = append(vars, gosnmp.SnmpPDU{Name: oid, Type: gosnmp.Null})
+	}
+
+	for try := 0; try <= m.retries; try++ {
+		m.Lock()
+		if m.closed {
+			m.Unlock()
+			resp.Err = ErrMultiTargetClosed
+			return resp
+		}
+		m.requestID++
+		id := m.requestID
+		ch := make(chan *multiReply, 1)
+		m.pending[id] = ch
+		m.Unlock()
+
+		req := &gosnmp.SnmpPacket{
+			Version:   m.version,
+			Community: m.community,
+			PDUType:   gosnmp.GetRequest,
+			RequestID: id,
+			Variables: vars,
+		}
+		b, err := req.MarshalMsg()
+		if err != nil {
+			m.forget(id)
+			resp.Err = errors.Wrap(err, "marshal request")
+			return resp
+		}
+		if _, err := m.conn.WriteTo(b, addr); err != nil {
+			m.forget(id)
+			resp.Err = errors.Wrapf(err, "send to %s", target)
+			return resp
+		}
+
+		select {
+		case reply, ok := <-ch:
+			if !ok {
+				resp.Err = ErrMultiTargetClosed
+				return resp
+			}
+			if !reply.source.IP.Equal(addr.IP) || reply.source.Port != addr.Port {
+				// a response to our request ID from someone else
+				continue
+			}
+			resp.Source = reply.source
+			resp.Values, resp.Err = multiValues(reply.packet)
+			return resp
+		case <-time.After(m.timeout):
+			m.forget(id)
+		}
+	}
+	resp.Err = errors.Errorf("request timeout for %s", target)
+	return resp
+}
+
+func (m *MultiTarget) forget(id uint32) {
+	m.Lock()
+	delete(m.pending, id)
+	m.Unlock()
+}
+
+// multiValues decodes the values of a response
+func multiValues(packet *gosnmp.SnmpPacket) (map[string]interface{}, error) {
+	if packet.Error != gosnmp.NoError {
+		return nil, errors.Errorf("get failed: %s", packet.Error)
+	}
+	values := make(map[string]interface{}, len(packet.Variables))
+	for _, pdu := range packet.Variables {
+		if !validPDU(pdu) {
+			continue
+		}
+		v, err := pduType(pdu)
+		if err != nil {
+			return values, err
+		}
+		values[pdu.Name] = v
+	}
+	return values, nil
+}
+
+// GetAll sends the GET to every target concurrently over the shared
+// socket, returning the responses in the order of targets
+func (m *MultiTarget) GetAll(targets []string, oids []string) []TargetResponse {
+	responses := make([]TargetResponse, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			responses[i] = m.Get(target, oids)
+		}(i, target)
+	}
+	wg.Wait()
+	return responses
+}
@@ -0,0 +1,56 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func nameAgent(t *testing.T, name string) string {
+	rp := replayer(t, Exchange{
+		PDUType:  gosnmp.GetRequest,
+		OIDs:     []string{sysName},
+		Response: []Record{{OID: sysName, Type: int32(gosnmp.OctetString), BytesVal: []byte(name)}},
+	})
+	addr, err := rp.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr.String()
+}
+
+func TestMultiTarget(t *testing.T) {
+	addr1 := nameAgent(t, "router1")
+	addr2 := nameAgent(t, "router2")
+
+	p := profileV2
+	p.Timeout, p.Retries = 1, 0
+	m, err := NewMultiTarget(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	responses := m.GetAll([]string{addr1, addr2}, []string{sysName})
+	for i, expect := range []string{"router1", "router2"} {
+		resp := responses[i]
+		if resp.Err != nil {
+			t.Fatal(resp.Err)
+		}
+		if resp.Source.String() != resp.Target {
+			t.Errorf("expected source %s, got %s", resp.Target, resp.Source)
+		}
+		if resp.Values[sysName] != expect {
+			t.Errorf("expected %s, got %v", expect, resp.Values[sysName])
+		}
+	}
+
+	m.Close()
+	if resp := m.Get(addr1, []string{sysName}); resp.Err != ErrMultiTargetClosed {
+		t.Errorf("expected closed error, got %v", resp.Err)
+	}
+}
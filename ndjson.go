@@ -0,0 +1,65 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/soniah/gosnmp"
+)
+
+// ndjsonFlush is how many lines are buffered between flushes
+const ndjsonFlush = 100
+
+// ndjsonLine is the object written for each varbind
+type ndjsonLine struct {
+	OID   string      `json:"oid"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// WalkNDJSON writes the values under rootOID to w as they are received,
+// as newline delimited JSON objects of oid, type and value. Output is
+// flushed periodically and the walk stops on a write or context error.
+func WalkNDJSON(ctx context.Context, client *gosnmp.GoSNMP, rootOID string, w io.Writer, opts ...WalkOption) error {
+	oid, err := getOID(rootOID)
+	if err != nil {
+		return err
+	}
+	bw, ok := w.(*bufio.Writer)
+	if !ok {
+		bw = bufio.NewWriter(w)
+	}
+	enc := json.NewEncoder(bw)
+	lines := 0
+	fn := func(pdu gosnmp.SnmpPDU) error {
+		if ctx != nil {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		value, err := pduType(pdu)
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(ndjsonLine{OID: pdu.Name, Type: pdu.Type.String(), Value: value}); err != nil {
+			return err
+		}
+		if lines++; lines%ndjsonFlush == 0 {
+			return bw.Flush()
+		}
+		return nil
+	}
+	err = withContext(ctx, client, func() error {
+		return walkFunc(client, opts...)(oid, fn)
+	})
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestWalkNDJSON(t *testing.T) {
+	client := replayAgent(t, ifDescrTranscript)
+
+	var out bytes.Buffer
+	if err := WalkNDJSON(context.Background(), client, ifDescr, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", out.String())
+	}
+	var line ndjsonLine
+	if err := json.Unmarshal([]byte(lines[1]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line.OID != ifDescr+".2" || line.Type != "OctetString" || line.Value != "eth0" {
+		t.Errorf("unexpected line: %+v", line)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := WalkNDJSON(ctx, client, ifDescr, &out); err == nil {
+		t.Error("expected error for cancelled context")
+	}
+}
+
+func TestWalkNDJSONStrategy(t *testing.T) {
+	str := func(oid, s string) []Record {
+		return []Record{{OID: oid, Type: int32(gosnmp.OctetString), BytesVal: []byte(s)}}
+	}
+	// an agent that only answers GETNEXT
+	rp := replayer(t,
+		Exchange{PDUType: gosnmp.GetNextRequest, OIDs: []string{ifDescr}, Response: str(ifDescr+".1", "lo")},
+		Exchange{PDUType: gosnmp.GetNextRequest, OIDs: []string{ifDescr + ".1"}, Response: str(ifDescr+".2", "eth0")},
+		Exchange{PDUType: gosnmp.GetNextRequest, OIDs: []string{ifDescr + ".2"}, Response: []Record{{OID: ifOperStatus + ".1", Type: int32(gosnmp.Integer), IntVal: 1}}},
+	)
+	client := replayClient(t, rp)
+	defer client.Conn.Close()
+	if err := SetWalkStrategy(client, "getnext"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a clock added within the call is kept, as is the strategy
+	var clock *engineClock
+	err := withContext(context.Background(), client, func() error {
+		clock = clockOf(client)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := WalkNDJSON(context.Background(), client, ifDescr, &out); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || rp.Misses() != 0 {
+		t.Errorf("expected 2 lines from GETNEXT alone, got %q with %d misses", out.String(), rp.Misses())
+	}
+	if walkStrategy(client) != "getnext" || clockOf(client) != clock {
+		t.Error("expected the client's settings to outlast the call")
+	}
+}
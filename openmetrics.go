@@ -0,0 +1,148 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MetricType is the OpenMetrics type of a metric family
+type MetricType string
+
+// The supported metric types
+const (
+	MetricGauge   MetricType = "gauge"
+	MetricCounter MetricType = "counter"
+)
+
+// Metric is a single sample for WriteOpenMetrics. Samples sharing a name
+// form a family and must agree on Type; the first non-empty Help is used.
+// A counter's name may be given with or without its _total suffix.
+type Metric struct {
+	Name   string
+	Help   string
+	Type   MetricType
+	Labels map[string]string
+	Value  float64
+}
+
+var (
+	metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelName  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	helpEscape  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscape = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// metricFamily is the samples of one name, in the order given
+type metricFamily struct {
+	name    string
+	help    string
+	typ     MetricType
+	samples []Metric
+}
+
+// WriteOpenMetrics writes the metrics in the OpenMetrics text format,
+// grouped by family in the order each was first seen, with TYPE and HELP
+// lines and the terminating # EOF. Labels are written in sorted order.
+func WriteOpenMetrics(w io.Writer, metrics []Metric) error {
+	var families []*metricFamily
+	byName := make(map[string]*metricFamily)
+	for _, m := range metrics {
+		if err := checkMetric(m); err != nil {
+			return err
+		}
+		name := m.Name
+		if m.Type == MetricCounter {
+			name = strings.TrimSuffix(name, "_total")
+		}
+		f, ok := byName[name]
+		if !ok {
+			f = &metricFamily{name: name, typ: m.Type}
+			byName[name] = f
+			families = append(families, f)
+		}
+		if f.typ != m.Type {
+			return errors.Errorf("metric %s is both a %s and a %s", name, f.typ, m.Type)
+		}
+		if len(f.help) == 0 {
+			f.help = m.Help
+		}
+		f.samples = append(f.samples, m)
+	}
+
+	b := bufio.NewWriter(w)
+	for _, f := range families {
+		b.WriteString("# TYPE " + f.name + " " + string(f.typ) + "\n")
+		if len(f.help) > 0 {
+			b.WriteString("# HELP " + f.name + " " + helpEscape.Replace(f.help) + "\n")
+		}
+		sample := f.name
+		if f.typ == MetricCounter {
+			sample += "_total"
+		}
+		for _, m := range f.samples {
+			b.WriteString(sample)
+			if len(m.Labels) > 0 {
+				b.WriteByte('{')
+				for i, k := range sortedKeys(m.Labels) {
+					if i > 0 {
+						b.WriteByte(',')
+					}
+					b.WriteString(k + `="` + labelEscape.Replace(m.Labels[k]) + `"`)
+				}
+				b.WriteByte('}')
+			}
+			b.WriteString(" " + metricValue(m.Value) + "\n")
+		}
+	}
+	b.WriteString("# EOF\n")
+	return b.Flush()
+}
+
+// checkMetric validates what OpenMetrics requires of a sample
+func checkMetric(m Metric) error {
+	if !metricName.MatchString(m.Name) {
+		return errors.Errorf("invalid metric name %q", m.Name)
+	}
+	switch m.Type {
+	case MetricGauge:
+	case MetricCounter:
+		if m.Value < 0 || math.IsNaN(m.Value) {
+			return errors.Errorf("invalid value %v for counter %s", m.Value, m.Name)
+		}
+	default:
+		return errors.Errorf("unsupported type %q for metric %s", m.Type, m.Name)
+	}
+	for k := range m.Labels {
+		if !labelName.MatchString(k) || strings.HasPrefix(k, "__") {
+			return errors.Errorf("invalid label name %q for metric %s", k, m.Name)
+		}
+	}
+	return nil
+}
+
+// metricValue renders a sample value, including the special values
+func metricValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case v == math.Trunc(v) && math.Abs(v) < 1<<53:
+		// counters read better without an exponent
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,49 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	metrics := []Metric{
+		{Name: "snmp_if_in_octets_total", Help: "Octets received.\nFrom ifHCInOctets", Type: MetricCounter, Labels: map[string]string{"ifName": "Gi0/1", "host": "r1"}, Value: 1234567890123},
+		{Name: "snmp_uptime_seconds", Type: MetricGauge, Labels: map[string]string{"host": "r1"}, Value: 86400.5},
+		{Name: "snmp_if_in_octets", Type: MetricCounter, Labels: map[string]string{"ifName": `odd "name" \ here`, "host": "r1"}, Value: 0},
+		{Name: "snmp_temp", Type: MetricGauge, Value: math.NaN()},
+	}
+	var b bytes.Buffer
+	if err := WriteOpenMetrics(&b, metrics); err != nil {
+		t.Fatal(err)
+	}
+	expect := `# TYPE snmp_if_in_octets counter
+# HELP snmp_if_in_octets Octets received.\nFrom ifHCInOctets
+snmp_if_in_octets_total{host="r1",ifName="Gi0/1"} 1234567890123
+snmp_if_in_octets_total{host="r1",ifName="odd \"name\" \\ here"} 0
+# TYPE snmp_uptime_seconds gauge
+snmp_uptime_seconds{host="r1"} 86400.5
+# TYPE snmp_temp gauge
+snmp_temp NaN
+# EOF
+`
+	if b.String() != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, b.String())
+	}
+
+	for _, bad := range [][]Metric{
+		{{Name: "1bad", Type: MetricGauge}},
+		{{Name: "ok", Type: "histogram"}},
+		{{Name: "ok", Type: MetricCounter, Value: -1}},
+		{{Name: "ok", Type: MetricGauge, Labels: map[string]string{"bad-label": "x"}}},
+		{{Name: "ok", Type: MetricGauge}, {Name: "ok", Type: MetricCounter}},
+	} {
+		if err := WriteOpenMetrics(&b, bad); err == nil {
+			t.Errorf("expected error for %+v", bad)
+		}
+	}
+}
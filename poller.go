@@ -6,8 +6,10 @@
 package snmputil
 
 import (
+	"hash/fnv"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"strings"
 	"sync"
 	"time"
@@ -57,6 +59,8 @@ type Criteria struct {
 	Count   int               // how many times to poll for data (0 is forever)
 	Freq    int               // how often to poll for data (in seconds)
 	Refresh int               // how often to refresh column data (in seconds)
+	Jitter  bool              // stagger the first poll by a random fraction of Freq
+	Seed    int64             // seed for jitter, combined with the host name
 }
 
 // ErrFunc processes errors and may be nil if desired
@@ -119,9 +123,10 @@ func bulkColumns(client *gosnmp.GoSNMP, crit Criteria, sender Sender, logger *lo
 	// get interface column names and aliases
 	suffixValue := func(oid string, lookup map[string]string) error {
 		fn := func(pdu gosnmp.SnmpPDU) error {
-			switch pdu.Type {
-			case gosnmp.OctetString:
-				lookup[pdu.Name[len(oid)+1:]] = cleanString(pdu.Value.([]byte))
+			b, ok := pdu.Value.([]byte)
+			switch {
+			case ok && pdu.Type == gosnmp.OctetString:
+				lookup[pdu.Name[len(oid)+1:]] = cleanString(b)
 			default:
 				return errors.Errorf("unknown type: %x value: %v\n", pdu.Type, pdu.Value)
 			}
@@ -133,8 +138,8 @@ func bulkColumns(client *gosnmp.GoSNMP, crit Criteria, sender Sender, logger *lo
 	// check for active interfaces
 	opStatus := func(pdu gosnmp.SnmpPDU) error {
 		const prefix = len(ifOperStatus) + 1
-		if pdu.Type == gosnmp.Integer {
-			enabled[pdu.Name[prefix:]] = pdu.Value.(int) == 1
+		if v, ok := pdu.Value.(int); ok && pdu.Type == gosnmp.Integer {
+			enabled[pdu.Name[prefix:]] = v == 1
 		}
 		return nil
 	}
@@ -305,12 +310,11 @@ func bulkWalker(client *gosnmp.GoSNMP, oid string, fn gosnmp.WalkFunc) error {
 	if len(oid) == 0 {
 		return errors.Errorf("no OID specified")
 	}
-	// snmp v1 doesn't support bulkwalk
-	walk := client.BulkWalkAll
-	if client.Version == gosnmp.Version1 {
-		walk = client.WalkAll
-	}
-	pdus, err := walk(oid)
+	var pdus []gosnmp.SnmpPDU
+	err := walkFunc(client)(oid, func(pdu gosnmp.SnmpPDU) error {
+		pdus = append(pdus, pdu)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
@@ -324,7 +328,7 @@ func bulkWalker(client *gosnmp.GoSNMP, oid string, fn gosnmp.WalkFunc) error {
 
 // setup preparse the snmp client and returns a walker function to handle bulkwalks
 func setup(p Profile, crit Criteria, sender Sender, logger *log.Logger) (string, *gosnmp.GoSNMP, gosnmp.WalkFunc, avgTime, *log.Logger, error) {
-	client, err := newClient(p)
+	client, err := NewClient(p)
 	if err != nil {
 		return "", nil, nil, nil, logger, err
 	}
@@ -352,6 +356,15 @@ func setup(p Profile, crit Criteria, sender Sender, logger *log.Logger) (string,
 	return crit.OID, client, walker, tCtl, logger, err
 }
 
+// jitter returns a delay within the polling period that is
+// deterministic for a given host and seed
+func jitter(host string, seed int64, freq int) time.Duration {
+	h := fnv.New64a()
+	h.Write([]byte(host))
+	r := rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+	return time.Duration(r.Int63n(int64(time.Duration(freq) * time.Second)))
+}
+
 // Sampler does a single bulkwalk on the device specified using the given Profile
 func Sampler(p Profile, c Criteria, s Sender) error {
 	oid, client, walker, avg, _, err := setup(p, c, s, nil)
@@ -360,11 +373,83 @@ func Sampler(p Profile, c Criteria, s Sender) error {
 	}
 	avg()
 	defer client.Conn.Close()
-	return bulkWalker(client, oid, walker)
+	err = bulkWalker(client, oid, walker)
+	if err != nil && rediscovering(client) {
+		// cached engine data was stale, retry with fresh discovery
+		err = bulkWalker(client, oid, walker)
+	}
+	return err
+}
+
+// PollerOption modifies the behavior of a Poller
+type PollerOption func(*pollerOptions)
+
+type pollerOptions struct {
+	deltaOnly bool
+	adaptive  *adaptiveInterval
+}
+
+// adaptiveInterval backs off the polling interval on consecutive timeouts
+type adaptiveInterval struct {
+	factor  float64
+	max     time.Duration
+	current time.Duration // 0 when at the baseline
+}
+
+// next returns the interval to wait after a poll that returned err,
+// or 0 to resume the baseline interval
+func (a *adaptiveInterval) next(err error, base time.Duration) time.Duration {
+	switch {
+	case err == nil:
+		a.current = 0
+	case isTimeout(err):
+		if a.current == 0 {
+			a.current = base
+		}
+		a.current = time.Duration(float64(a.current) * a.factor)
+		if a.current > a.max {
+			a.current = a.max
+		}
+	}
+	return a.current
+}
+
+// WithDeltaOnly only sends values that differ from the prior polling cycle.
+// The first cycle sends all values as a baseline, and values that are
+// no longer present are sent with a nil value.
+func WithDeltaOnly() PollerOption {
+	return func(o *pollerOptions) {
+		o.deltaOnly = true
+	}
+}
+
+// WithAdaptiveInterval multiplies the polling interval by factor for each
+// consecutive poll that times out, up to max, so that a struggling
+// device is polled less often. The interval returns to the Criteria's
+// Freq on the next successful poll.
+func WithAdaptiveInterval(factor float64, max time.Duration) PollerOption {
+	return func(o *pollerOptions) {
+		if factor > 1 && max > 0 {
+			o.adaptive = &adaptiveInterval{factor: factor, max: max}
+		}
+	}
 }
 
 // Poller does a bulkwalk on the device specified in the Profile
-func Poller(p Profile, c Criteria, s Sender, fn ErrFunc, l *log.Logger) error {
+func Poller(p Profile, c Criteria, s Sender, fn ErrFunc, l *log.Logger, opts ...PollerOption) error {
+	var o pollerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var delta *deltaSender
+	if o.deltaOnly {
+		if s == nil {
+			s, _ = DebugSender(nil, nil)
+		}
+		delta = newDeltaSender(s)
+		s = delta.send
+	}
+
 	oid, client, walker, avg, l, err := setup(p, c, s, l)
 	if err != nil {
 		return err
@@ -377,13 +462,19 @@ func Poller(p Profile, c Criteria, s Sender, fn ErrFunc, l *log.Logger) error {
 		name = oid
 	}
 
-	// snmp v1 doesn't support bulkwalk
-	walk := client.BulkWalk
-	if p.Version == "1" {
-		walk = client.Walk
-	}
+	walk := walkFunc(client)
 
 	defer client.Conn.Close()
+
+	// spread out hosts polled on the same interval
+	if c.Jitter && freq > 0 {
+		select {
+		case <-time.After(jitter(p.Host, c.Seed, freq)):
+		case <-done:
+			return nil
+		}
+	}
+
 	clk := time.Tick(time.Duration(delay) * time.Second)
 	for {
 		// if the last request took longer than the polling frequency
@@ -409,6 +500,14 @@ func Poller(p Profile, c Criteria, s Sender, fn ErrFunc, l *log.Logger) error {
 		if err = walk(oid, walker); err != nil {
 			l.Println(errors.Wrap(err, "snmp walk failed"))
 		}
+		if delta != nil {
+			// a failed walk would make every value look removed
+			if err != nil {
+				delta.discard()
+			} else if ferr := delta.flush(); ferr != nil {
+				l.Println(errors.Wrap(ferr, "delta send failed"))
+			}
+		}
 
 		// errors represent an event occurred, for stats
 		if fn != nil {
@@ -422,6 +521,22 @@ func Poller(p Profile, c Criteria, s Sender, fn ErrFunc, l *log.Logger) error {
 			}
 		}
 
+		var backoff <-chan time.Time
+		if o.adaptive != nil {
+			if wait := o.adaptive.next(err, time.Duration(delay)*time.Second); wait > 0 {
+				l.Printf("Backing off poll for %s/%s to %s after timeout\n", client.Target, name, wait)
+				backoff = time.After(wait)
+			}
+		}
+		if backoff != nil {
+			select {
+			case <-backoff:
+				continue
+			case <-done:
+				return nil
+			}
+		}
+
 		select {
 		case _ = <-clk:
 			continue
@@ -457,7 +572,7 @@ func (c *Collector) List() []string {
 
 // Poll actively collects OIDs from the SNMP device
 func (c *Collector) Poll(p Profile, oid string) error {
-	client, err := newClient(p)
+	client, err := NewClient(p)
 	if err != nil {
 		return err
 	}
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
 const (
@@ -95,3 +97,32 @@ func TestClose(t *testing.T) {
 	time.Sleep(5 * time.Second)
 	Quit()
 }
+
+func TestJitter(t *testing.T) {
+	a := jitter("host1", 42, testFreq)
+	if b := jitter("host1", 42, testFreq); a != b {
+		t.Errorf("jitter not deterministic: %s != %s", a, b)
+	}
+	if a < 0 || a >= testFreq*time.Second {
+		t.Errorf("jitter out of range: %s", a)
+	}
+	if b := jitter("host2", 42, testFreq); a == b {
+		t.Errorf("expected hosts to be staggered: %s", a)
+	}
+}
+
+func TestAdaptiveInterval(t *testing.T) {
+	a := &adaptiveInterval{factor: 2, max: 5 * time.Minute}
+	base := time.Minute
+	for _, want := range []time.Duration{2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		if got := a.next(testNetError{}, base); got != want {
+			t.Errorf("expected %s after timeout, got %s", want, got)
+		}
+	}
+	if got := a.next(errors.New("unknown user name"), base); got != 5*time.Minute {
+		t.Errorf("expected other errors to leave the interval, got %s", got)
+	}
+	if got := a.next(nil, base); got != 0 {
+		t.Errorf("expected baseline after success, got %s", got)
+	}
+}
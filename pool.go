@@ -0,0 +1,169 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+// PoolOption modifies the behavior of a Pool
+type PoolOption func(*Pool)
+
+// RefreshInterval checks sessions idle for at least d every d, evicting
+// those whose agent no longer responds so the next Get reconnects
+func RefreshInterval(d time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.refresh = d
+	}
+}
+
+// PoolClientOptions applies opts to the clients created by the pool
+func PoolClientOptions(opts ...ClientOption) PoolOption {
+	return func(p *Pool) {
+		p.opts = opts
+	}
+}
+
+// Pool keeps a Session per host for reuse across polls
+type Pool struct {
+	sync.Mutex
+	sessions map[string]*Session
+	opts     []ClientOption
+	refresh  time.Duration
+	done     chan struct{}
+	closing  sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewPool returns an empty pool
+func NewPool(opts ...PoolOption) *Pool {
+	p := &Pool{
+		sessions: make(map[string]*Session),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.refresh > 0 {
+		p.wg.Add(1)
+		go p.refresher()
+	}
+	return p
+}
+
+// poolKey identifies the host and the credentials used for it, so a
+// session is not shared by profiles with different credentials
+func poolKey(p Profile) string {
+	port := p.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%q", []string{p.Version, p.Community, strings.Join(p.Communities, ","),
+		p.SecLevel, p.AuthUser, p.AuthPass, p.AuthProto, p.PrivProto, p.PrivPass,
+		p.AuthEngineID, p.ContextEngineID, p.ContextName})
+	return net.JoinHostPort(p.Host, strconv.Itoa(port)) + "/" + hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the pooled session for the profile's host and credentials,
+// creating it if need be
+func (p *Pool) Get(profile Profile) (*Session, error) {
+	key := poolKey(profile)
+	p.Lock()
+	s, ok := p.sessions[key]
+	p.Unlock()
+	if ok {
+		return s, nil
+	}
+	// not locked, so a slow host lookup doesn't hold up other hosts
+	s, err := NewSession(profile, p.opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.Lock()
+	defer p.Unlock()
+	if pooled, ok := p.sessions[key]; ok {
+		// created by another caller meanwhile
+		s.Close()
+		return pooled, nil
+	}
+	p.sessions[key] = s
+	return s, nil
+}
+
+// Len returns the number of sessions in the pool
+func (p *Pool) Len() int {
+	p.Lock()
+	defer p.Unlock()
+	return len(p.sessions)
+}
+
+// evict removes the session from the pool, if still present, and closes it
+func (p *Pool) evict(key string, s *Session) {
+	p.Lock()
+	if p.sessions[key] == s {
+		delete(p.sessions, key)
+	}
+	p.Unlock()
+	s.Close()
+}
+
+func (p *Pool) refresher() {
+	defer p.wg.Done()
+	tick := time.NewTicker(p.refresh)
+	defer tick.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-tick.C:
+			p.check()
+		}
+	}
+}
+
+// check probes each idle session with a sysUpTime GET
+func (p *Pool) check() {
+	p.Lock()
+	sessions := make(map[string]*Session, len(p.sessions))
+	for key, s := range p.sessions {
+		sessions[key] = s
+	}
+	p.Unlock()
+	for key, s := range sessions {
+		if s.idle() < p.refresh {
+			continue
+		}
+		err := s.Do(func(client *gosnmp.GoSNMP) error {
+			_, err := client.Get([]string{sysUpTime})
+			return err
+		})
+		if err != nil {
+			p.evict(key, s)
+		}
+	}
+}
+
+// Close stops the refresher and closes all sessions
+func (p *Pool) Close() error {
+	p.closing.Do(func() { close(p.done) })
+	p.wg.Wait()
+	p.Lock()
+	defer p.Unlock()
+	for key, s := range p.sessions {
+		s.Close()
+		delete(p.sessions, key)
+	}
+	return nil
+}
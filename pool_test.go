@@ -0,0 +1,79 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestPoolRefresh(t *testing.T) {
+	agent := replayer(t, Exchange{
+		PDUType:  gosnmp.GetRequest,
+		OIDs:     []string{sysUpTime},
+		Response: []Record{{OID: sysUpTime, Type: int32(gosnmp.TimeTicks), IntVal: 100}},
+	})
+	addr, err := agent.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := NewPool(RefreshInterval(20 * time.Millisecond))
+	defer pool.Close()
+	p := profileV2
+	p.Host, p.Port, p.Timeout, p.Retries = "127.0.0.1", addr.Port, 1, 0
+	s, err := pool.Get(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := pool.Get(p); again != s {
+		t.Error("expected the pooled session to be reused")
+	}
+	if _, _, err := s.Get([]string{sysUpTime}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a live agent keeps its session
+	time.Sleep(100 * time.Millisecond)
+	if pool.Len() != 1 {
+		t.Fatal("expected live session to remain pooled")
+	}
+
+	// the agent goes away, so the connection is dead
+	agent.Close()
+	deadline := time.Now().Add(3 * time.Second)
+	for pool.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if pool.Len() != 0 {
+		t.Fatal("expected dead session to be evicted")
+	}
+	if fresh, _ := pool.Get(p); fresh == s {
+		t.Error("expected a new session after eviction")
+	}
+}
+
+func TestPoolCredentials(t *testing.T) {
+	pool := NewPool()
+	p := profileV2
+	s, err := pool.Get(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := p
+	other.Community = "private"
+	if o, err := pool.Get(other); err != nil || o == s {
+		t.Errorf("expected a session per community, got %v", err)
+	}
+	if again, _ := pool.Get(p); again != s || pool.Len() != 2 {
+		t.Errorf("expected the session to be reused, have %d", pool.Len())
+	}
+	pool.Close()
+	if err := pool.Close(); err != nil {
+		t.Errorf("expected a second close to be harmless, got %v", err)
+	}
+}
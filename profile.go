@@ -0,0 +1,55 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const redacted = "REDACTED"
+
+// profileFields is a Profile without its methods, for formatting
+type profileFields Profile
+
+// redact returns a copy of p with its secrets masked. Empty secrets are
+// left empty so a missing credential is still apparent.
+func (p Profile) redact() profileFields {
+	mask := func(s string) string {
+		if len(s) == 0 {
+			return s
+		}
+		return redacted
+	}
+	r := profileFields(p)
+	r.Community = mask(p.Community)
+	r.AuthPass = mask(p.AuthPass)
+	r.PrivPass = mask(p.PrivPass)
+	if len(p.Communities) > 0 {
+		r.Communities = make([]string, len(p.Communities))
+		for i, c := range p.Communities {
+			r.Communities[i] = mask(c)
+		}
+	}
+	return r
+}
+
+// String returns the profile with its communities and passwords redacted,
+// so that it is safe to log
+func (p Profile) String() string {
+	return fmt.Sprintf("%+v", p.redact())
+}
+
+// GoString is String for the %#v verb
+func (p Profile) GoString() string {
+	return fmt.Sprintf("%#v", p.redact())
+}
+
+// ExportSecure returns the profile as JSON including its secrets, e.g.,
+// for handing to a child process over a secure channel. It must not be
+// used for logging; use String instead.
+func (p Profile) ExportSecure() ([]byte, error) {
+	return json.Marshal(profileFields(p))
+}
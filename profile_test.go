@@ -0,0 +1,51 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProfileRedacted(t *testing.T) {
+	p := Profile{
+		Host:        "router1",
+		Community:   "s3cret-community",
+		Communities: []string{"alt-community"},
+		Version:     "3",
+		AuthUser:    testUser,
+		AuthPass:    "auth-passphrase",
+		PrivPass:    "priv-passphrase",
+	}
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+		s := fmt.Sprintf(verb, p)
+		for _, secret := range []string{p.Community, p.Communities[0], p.AuthPass, p.PrivPass} {
+			if strings.Contains(s, secret) {
+				t.Errorf("%s leaks %q: %s", verb, secret, s)
+			}
+		}
+		if !strings.Contains(s, "router1") || !strings.Contains(s, redacted) {
+			t.Errorf("%s: unexpected format: %s", verb, s)
+		}
+	}
+	if p.Communities[0] != "alt-community" {
+		t.Error("redacting changed the profile")
+	}
+
+	b, err := p.ExportSecure()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Profile
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("expected %#v, got %#v", profileFields(p), profileFields(got))
+	}
+}
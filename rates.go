@@ -0,0 +1,88 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+// IfSnapshot is the traffic counters of every interface at a point in time
+type IfSnapshot struct {
+	Time  time.Time
+	Stats []IfCounters // as from InterfaceStats
+}
+
+// IfRate is the traffic of an interface per second between two snapshots
+type IfRate struct {
+	InBps, OutBps                 float64 // bits
+	InPps, OutPps                 float64 // unicast packets
+	InErrorRate, OutErrorRate     float64
+	InDiscardRate, OutDiscardRate float64
+	Interval                      time.Duration
+}
+
+// InterfaceSnapshot returns InterfaceStats with the time it was taken
+func InterfaceSnapshot(client *gosnmp.GoSNMP) (*IfSnapshot, error) {
+	stats, err := InterfaceStats(client)
+	if err != nil {
+		return nil, err
+	}
+	return &IfSnapshot{Time: time.Now(), Stats: stats}, nil
+}
+
+// counterDelta returns the increase of a counter of the given width in
+// bits, allowing for it to have wrapped
+func counterDelta(prev, cur uint64, width uint) uint64 {
+	if width >= 64 {
+		return cur - prev
+	}
+	return (cur - prev) & (1<<width - 1)
+}
+
+// RateTable returns the rates of each interface between two snapshots,
+// keyed by ifIndex. Interfaces missing from either snapshot, or whose
+// counter width changed between them, are skipped.
+func RateTable(prev, cur *IfSnapshot) map[int]IfRate {
+	rates := make(map[int]IfRate)
+	if prev == nil || cur == nil {
+		return rates
+	}
+	interval := cur.Time.Sub(prev.Time)
+	if interval <= 0 {
+		return rates
+	}
+	secs := interval.Seconds()
+	before := make(map[int]IfCounters, len(prev.Stats))
+	for _, p := range prev.Stats {
+		before[p.IfIndex] = p
+	}
+	for _, c := range cur.Stats {
+		p, ok := before[c.IfIndex]
+		if !ok || p.HighCapacity != c.HighCapacity {
+			continue
+		}
+		width := uint(32)
+		if c.HighCapacity {
+			width = 64
+		}
+		rate := func(prev, cur uint64, width uint) float64 {
+			return float64(counterDelta(prev, cur, width)) / secs
+		}
+		rates[c.IfIndex] = IfRate{
+			InBps:          8 * rate(p.InOctets, c.InOctets, width),
+			OutBps:         8 * rate(p.OutOctets, c.OutOctets, width),
+			InPps:          rate(p.InPkts, c.InPkts, width),
+			OutPps:         rate(p.OutPkts, c.OutPkts, width),
+			InErrorRate:    rate(p.InErrors, c.InErrors, 32),
+			OutErrorRate:   rate(p.OutErrors, c.OutErrors, 32),
+			InDiscardRate:  rate(p.InDiscards, c.InDiscards, 32),
+			OutDiscardRate: rate(p.OutDiscards, c.OutDiscards, 32),
+			Interval:       interval,
+		}
+	}
+	return rates
+}
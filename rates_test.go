@@ -0,0 +1,61 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRateTable(t *testing.T) {
+	start := time.Unix(1476451815, 0)
+	traffic := func(index int, hc bool, octets, errs uint64) IfCounters {
+		return IfCounters{
+			IfIndex:      index,
+			InOctets:     octets,
+			OutOctets:    octets,
+			InPkts:       octets / 100,
+			InErrors:     errs,
+			HighCapacity: hc,
+		}
+	}
+	prev := &IfSnapshot{Time: start, Stats: []IfCounters{
+		traffic(1, true, 1000, 0),
+		traffic(2, false, math.MaxUint32-499, math.MaxUint32),
+		traffic(3, true, math.MaxUint64-9, 0),
+		traffic(4, false, 0, 0),
+		traffic(5, false, 0, 0),
+	}}
+	cur := &IfSnapshot{Time: start.Add(10 * time.Second), Stats: []IfCounters{
+		traffic(1, true, 11000, 5),
+		traffic(2, false, 500, 9),
+		traffic(3, true, 90, 0),
+		traffic(5, true, 100, 0), // the agent now has HC counters
+		traffic(6, false, 100, 0),
+	}}
+	rates := RateTable(prev, cur)
+	if len(rates) != 3 {
+		t.Fatalf("expected 3 interfaces, got %v", rates)
+	}
+	for index, expect := range map[int]IfRate{
+		1: {InBps: 8000, OutBps: 8000, InErrorRate: 0.5},
+		// 32 bit counters wrapped
+		2: {InBps: 800, OutBps: 800, InErrorRate: 1},
+		// 64 bit counters wrapped
+		3: {InBps: 80, OutBps: 80},
+	} {
+		got := rates[index]
+		if got.InBps != expect.InBps || got.OutBps != expect.OutBps || got.InErrorRate != expect.InErrorRate || got.Interval != 10*time.Second {
+			t.Errorf("%d: expected %+v, got %+v", index, expect, got)
+		}
+	}
+	if rates[1].InPps != 10 {
+		t.Errorf("expected 10 packets per second, got %v", rates[1].InPps)
+	}
+	if len(RateTable(cur, prev)) != 0 || len(RateTable(nil, cur)) != 0 {
+		t.Error("expected no rates for reversed or missing snapshots")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"github.com/soniah/gosnmp"
+)
+
+// resolveOIDs resolves any names in oids to their numeric form
+func resolveOIDs(oids []string) ([]string, error) {
+	fixed := make([]string, 0, len(oids))
+	for _, oid := range oids {
+		o, err := getOID(oid)
+		if err != nil {
+			return nil, err
+		}
+		fixed = append(fixed, o)
+	}
+	return fixed, nil
+}
+
+// rawRequest sends the request to the connected client, returning the
+// response as is. A v3 report is returned as an error along with the packet.
+func rawRequest(client *gosnmp.GoSNMP, oids []string, send func([]string) (*gosnmp.SnmpPacket, error)) (*gosnmp.SnmpPacket, error) {
+	oids, err := resolveOIDs(oids)
+	if err != nil {
+		return nil, err
+	}
+	if err := connected(client); err != nil {
+		return nil, err
+	}
+	packet, err := send(oids)
+	if err == nil {
+		err = packetError(client, packet)
+	}
+	recordStats(client, err)
+	return packet, err
+}
+
+// GetRaw sends a GET for the OIDs, which may be given by name, and returns
+// the agent's response undecoded, for callers needing its error-status,
+// error-index, request ID or security parameters. An error-status in the
+// response is not treated as an error; that is left to the caller.
+func GetRaw(client *gosnmp.GoSNMP, oids []string) (*gosnmp.SnmpPacket, error) {
+	return rawRequest(client, oids, client.Get)
+}
+
+// GetNextRaw is GetRaw for a GETNEXT
+func GetNextRaw(client *gosnmp.GoSNMP, oids []string) (*gosnmp.SnmpPacket, error) {
+	return rawRequest(client, oids, client.GetNext)
+}
+
+// GetBulkRaw is GetRaw for a GETBULK
+func GetBulkRaw(client *gosnmp.GoSNMP, oids []string, nonRepeaters, maxRepetitions uint8) (*gosnmp.SnmpPacket, error) {
+	return rawRequest(client, oids, func(oids []string) (*gosnmp.SnmpPacket, error) {
+		return client.GetBulk(oids, nonRepeaters, maxRepetitions)
+	})
+}
@@ -0,0 +1,60 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestGetRaw(t *testing.T) {
+	const location = ".1.3.6.1.2.1.1.6.0"
+	agent := replayer(t,
+		Exchange{
+			PDUType:    gosnmp.GetRequest,
+			OIDs:       []string{sysName, location},
+			Error:      gosnmp.GenErr,
+			ErrorIndex: 2,
+			Response: []Record{
+				{OID: sysName, Type: int32(gosnmp.OctetString), BytesVal: []byte("router1")},
+				{OID: location, Type: int32(gosnmp.Null)},
+			},
+		},
+		Exchange{
+			PDUType:  gosnmp.GetNextRequest,
+			OIDs:     []string{sysName},
+			Response: []Record{{OID: location, Type: int32(gosnmp.OctetString), BytesVal: []byte("lab")}},
+		},
+	)
+	client := replayClient(t, agent)
+	defer client.Conn.Close()
+
+	packet, err := GetRaw(client, []string{sysName, location})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packet.Error != gosnmp.GenErr || packet.ErrorIndex != 2 {
+		t.Errorf("expected genErr at index 2, got %s at %d", packet.Error, packet.ErrorIndex)
+	}
+	if packet.Community != client.Community || len(packet.Variables) != 2 {
+		t.Errorf("unexpected response: %+v", packet)
+	}
+
+	packet, err = GetNextRaw(client, []string{sysName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packet.Variables) != 1 || packet.Variables[0].Name != location {
+		t.Errorf("unexpected response: %+v", packet.Variables)
+	}
+	if agent.Misses() != 0 {
+		t.Errorf("expected no misses, got %d", agent.Misses())
+	}
+
+	if _, err := GetRaw(client, []string{"noSuchName"}); err == nil {
+		t.Error("expected an unknown name to fail")
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"sync"
+	"time"
+)
+
+// timeTicksWrap is when a sysUpTime (hundredths of a second in 32 bits) wraps to 0,
+// about 497 days
+const timeTicksWrap = (1 << 32) * 10 * time.Millisecond
+
+type upTimeSample struct {
+	upTime time.Duration
+	seen   time.Time
+}
+
+// RebootDetector tracks the sysUpTime of hosts across polls to detect reboots
+type RebootDetector struct {
+	sync.Mutex
+	hosts map[string]upTimeSample
+	now   func() time.Time
+}
+
+// NewRebootDetector returns an empty detector
+func NewRebootDetector() *RebootDetector {
+	return &RebootDetector{
+		hosts: make(map[string]upTimeSample),
+		now:   time.Now,
+	}
+}
+
+// Check records the host's current uptime and reports whether it has
+// rebooted since the last check, i.e., its uptime went down. A decrease
+// consistent with the TimeTicks counter wrapping, given the time between
+// checks, is not considered a reboot. The first check of a host is always false.
+func (r *RebootDetector) Check(host string, upTime time.Duration) bool {
+	r.Lock()
+	defer r.Unlock()
+	now := r.now()
+	prev, ok := r.hosts[host]
+	r.hosts[host] = upTimeSample{upTime: upTime, seen: now}
+	if !ok || upTime >= prev.upTime {
+		return false
+	}
+	elapsed := now.Sub(prev.seen)
+	expected := prev.upTime + elapsed
+	slack := time.Minute + elapsed/10
+	if expected+slack >= timeTicksWrap && upTime <= expected-timeTicksWrap+slack {
+		return false
+	}
+	return true
+}
+
+// Forget stops tracking host
+func (r *RebootDetector) Forget(host string) {
+	r.Lock()
+	delete(r.hosts, host)
+	r.Unlock()
+}
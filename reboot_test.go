@@ -0,0 +1,39 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRebootDetector(t *testing.T) {
+	now := time.Now()
+	r := NewRebootDetector()
+	r.now = func() time.Time { return now }
+	poll := time.Duration(testFreq) * time.Second
+
+	check := func(upTime time.Duration, expect bool, msg string) {
+		if got := r.Check(testHost, upTime); got != expect {
+			t.Errorf("%s: expected %t, got %t", msg, expect, got)
+		}
+		now = now.Add(poll)
+	}
+
+	check(time.Hour, false, "first poll")
+	check(time.Hour+poll, false, "uptime increasing")
+	check(10*time.Second, true, "uptime reset")
+	check(10*time.Second+poll, false, "after reboot")
+
+	// just short of wrapping, then wrapped
+	r.Forget(testHost)
+	check(timeTicksWrap-10*time.Second, false, "near wrap")
+	check(poll-10*time.Second, false, "counter wrap")
+
+	// near the wrap point but the device really rebooted well before it wrapped
+	r.Forget(testHost)
+	check(timeTicksWrap-time.Hour, false, "an hour before wrap")
+	check(5*time.Second, true, "reboot near wrap")
+}
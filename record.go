@@ -0,0 +1,100 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// Record is a flattened varbind suitable for mapping to a protobuf message.
+// Type is the ASN.1 BER type of the value (e.g., gosnmp.Counter64) and
+// determines which value field is set: IntVal for the numeric types,
+// BytesVal for octet strings, and StrVal for OIDs, addresses and floats.
+// Counter64 values are stored in IntVal as their uint64 bit pattern.
+type Record struct {
+	OID      string
+	Type     int32
+	IntVal   int64
+	BytesVal []byte
+	StrVal   string
+}
+
+// NewRecord converts a pdu to a Record
+func NewRecord(pdu gosnmp.SnmpPDU) (Record, error) {
+	r := Record{OID: pdu.Name, Type: int32(pdu.Type)}
+	switch v := pdu.Value.(type) {
+	case nil:
+	case int:
+		r.IntVal = int64(v)
+	case uint:
+		r.IntVal = int64(v)
+	case uint32:
+		r.IntVal = int64(v)
+	case uint64:
+		r.IntVal = int64(v)
+	case []byte:
+		r.BytesVal = v
+	case string:
+		r.StrVal = v
+	case float32:
+		r.StrVal = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		r.StrVal = strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return r, errors.Errorf("unsupported type for %s: %x (%T)", pdu.Name, pdu.Type, pdu.Value)
+	}
+	return r, nil
+}
+
+// PDU converts the record back to a pdu, with its value typed as gosnmp returns it
+func (r Record) PDU() (gosnmp.SnmpPDU, error) {
+	pdu := gosnmp.SnmpPDU{Name: r.OID, Type: gosnmp.Asn1BER(r.Type)}
+	switch pdu.Type {
+	case gosnmp.Integer:
+		pdu.Value = int(r.IntVal)
+	case gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Uinteger32:
+		pdu.Value = uint32(r.IntVal)
+	case gosnmp.Counter64:
+		pdu.Value = uint64(r.IntVal)
+	case gosnmp.OctetString, gosnmp.BitString, gosnmp.Opaque:
+		pdu.Value = r.BytesVal
+	case gosnmp.ObjectIdentifier, gosnmp.IPAddress:
+		pdu.Value = r.StrVal
+	case gosnmp.OpaqueFloat:
+		f, err := strconv.ParseFloat(r.StrVal, 32)
+		if err != nil {
+			return pdu, errors.Wrapf(err, "invalid float for %s", r.OID)
+		}
+		pdu.Value = float32(f)
+	case gosnmp.OpaqueDouble:
+		f, err := strconv.ParseFloat(r.StrVal, 64)
+		if err != nil {
+			return pdu, errors.Wrapf(err, "invalid double for %s", r.OID)
+		}
+		pdu.Value = f
+	case gosnmp.Null, gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView:
+	default:
+		return pdu, errors.Errorf("unsupported type for %s: %x", r.OID, r.Type)
+	}
+	return pdu, nil
+}
+
+// WalkRecords walks rootOID and applies fn to each value as a Record
+func WalkRecords(client *gosnmp.GoSNMP, rootOID string, fn func(Record) error) error {
+	oid, err := getOID(rootOID)
+	if err != nil {
+		return err
+	}
+	return walkFunc(client)(oid, func(pdu gosnmp.SnmpPDU) error {
+		r, err := NewRecord(pdu)
+		if err != nil {
+			return err
+		}
+		return fn(r)
+	})
+}
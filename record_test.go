@@ -0,0 +1,57 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestNewRecord(t *testing.T) {
+	r, err := NewRecord(gosnmp.SnmpPDU{Name: ".1.2", Type: gosnmp.Counter64, Value: uint64(math.MaxUint64)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Type != int32(gosnmp.Counter64) || uint64(r.IntVal) != math.MaxUint64 {
+		t.Errorf("unexpected counter record: %+v", r)
+	}
+	r, err = NewRecord(gosnmp.SnmpPDU{Name: ".1.3", Type: gosnmp.OctetString, Value: []byte("eth0")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(r.BytesVal) != "eth0" {
+		t.Errorf("unexpected string record: %+v", r)
+	}
+	if _, err := NewRecord(gosnmp.SnmpPDU{Name: ".1.4", Value: struct{}{}}); err == nil {
+		t.Error("expected error for unknown value type")
+	}
+}
+
+func TestRecordPDU(t *testing.T) {
+	pdus := []gosnmp.SnmpPDU{
+		{Name: ".1.1", Type: gosnmp.Integer, Value: -5},
+		{Name: ".1.2", Type: gosnmp.Counter32, Value: uint32(7)},
+		{Name: ".1.3", Type: gosnmp.Counter64, Value: uint64(1) << 63},
+		{Name: ".1.4", Type: gosnmp.OctetString, Value: []byte("abc")},
+		{Name: ".1.5", Type: gosnmp.IPAddress, Value: "10.0.0.1"},
+		{Name: ".1.6", Type: gosnmp.EndOfMibView},
+	}
+	for _, pdu := range pdus {
+		r, err := NewRecord(pdu)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.PDU()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, pdu) {
+			t.Errorf("expected %+v, got %+v", pdu, got)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// Exchange is a request and its response as captured in a transcript
+type Exchange struct {
+	PDUType        gosnmp.PDUType
+	OIDs           []string
+	NonRepeaters   uint8            `json:",omitempty"`
+	MaxRepetitions uint8            `json:",omitempty"`
+	Error          gosnmp.SNMPError `json:",omitempty"`
+	ErrorIndex     uint8            `json:",omitempty"`
+	Response       []Record
+}
+
+// key identifies the request for matching during replay
+func (e Exchange) key() string {
+	return fmt.Sprintf("%d:%d:%d:%s", e.PDUType, e.NonRepeaters, e.MaxRepetitions, strings.Join(e.OIDs, ","))
+}
+
+func requestExchange(packet *gosnmp.SnmpPacket) Exchange {
+	e := Exchange{
+		PDUType:        packet.PDUType,
+		OIDs:           make([]string, 0, len(packet.Variables)),
+		NonRepeaters:   packet.NonRepeaters,
+		MaxRepetitions: packet.MaxRepetitions,
+	}
+	for _, pdu := range packet.Variables {
+		e.OIDs = append(e.OIDs, pdu.Name)
+	}
+	return e
+}
+
+// Recorder captures the requests and responses of a client as a
+// transcript of JSON encoded Exchanges, one per line
+type Recorder struct {
+	net.Conn
+	sync.Mutex
+	decoder *gosnmp.GoSNMP
+	enc     *json.Encoder
+	pending map[uint32]Exchange
+	err     error
+}
+
+// NewRecorder connects the client if need be and records its traffic to w.
+// Only v1 and v2c clients are supported. If the client reconnects, a
+// new recorder must be attached.
+func NewRecorder(client *gosnmp.GoSNMP, w io.Writer) (*Recorder, error) {
+	if client.Version == gosnmp.Version3 {
+		return nil, errors.New("recording is not supported for SNMP v3")
+	}
+	if err := connected(client); err != nil {
+		return nil, err
+	}
+	r := &Recorder{
+		Conn:    client.Conn,
+		decoder: &gosnmp.GoSNMP{Version: client.Version, Community: client.Community},
+		enc:     json.NewEncoder(w),
+		pending: make(map[uint32]Exchange),
+	}
+	client.Conn = r
+	return r, nil
+}
+
+// Err returns the first error encountered while recording, if any
+func (r *Recorder) Err() error {
+	r.Lock()
+	defer r.Unlock()
+	return r.err
+}
+
+func (r *Recorder) save(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Write notes the outgoing request before sending it
+func (r *Recorder) Write(b []byte) (int, error) {
+	packet, err := r.decoder.SnmpDecodePacket(b)
+	r.Lock()
+	if err != nil {
+		r.save(errors.Wrap(err, "decode request"))
+	} else {
+		r.pending[packet.RequestID] = requestExchange(packet)
+	}
+	r.Unlock()
+	return r.Conn.Write(b)
+}
+
+// Read writes the exchange to the transcript once its response is received
+func (r *Recorder) Read(b []byte) (int, error) {
+	n, err := r.Conn.Read(b)
+	if err != nil {
+		return n, err
+	}
+	packet, derr := r.decoder.SnmpDecodePacket(b[:n])
+	r.Lock()
+	defer r.Unlock()
+	if derr != nil {
+		r.save(errors.Wrap(derr, "decode response"))
+		return n, err
+	}
+	e, ok := r.pending[packet.RequestID]
+	if !ok {
+		return n, err
+	}
+	delete(r.pending, packet.RequestID)
+	e.Error, e.ErrorIndex = packet.Error, packet.ErrorIndex
+	for _, pdu := range packet.Variables {
+		rec, rerr := NewRecord(pdu)
+		if rerr != nil {
+			r.save(rerr)
+			return n, err
+		}
+		e.Response = append(e.Response, rec)
+	}
+	r.save(r.enc.Encode(e))
+	return n, err
+}
+
+// Replayer is a mock agent that answers requests from a transcript made
+// by a Recorder. Repeated requests are answered with successive matching
+// exchanges, the last being reused once exhausted. Requests not in the
+// transcript are answered with noSuchObject.
+type Replayer struct {
+	sync.Mutex
+	exchanges map[string][]Exchange
+	conn      net.PacketConn
+	misses    int
+	done      chan struct{}
+}
+
+// NewReplayer loads a transcript
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	rp := &Replayer{exchanges: make(map[string][]Exchange)}
+	dec := json.NewDecoder(r)
+	for {
+		var e Exchange
+		if err := dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "invalid transcript")
+		}
+		k := e.key()
+		rp.exchanges[k] = append(rp.exchanges[k], e)
+	}
+	return rp, nil
+}
+
+// Listen starts answering requests on the UDP address, returning the address bound
+func (rp *Replayer) Listen(addr string) (*net.UDPAddr, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listen %s", addr)
+	}
+	rp.conn = conn
+	rp.done = make(chan struct{})
+	go rp.serve()
+	return conn.LocalAddr().(*net.UDPAddr), nil
+}
+
+// Close stops the replayer
+func (rp *Replayer) Close() error {
+	if rp.conn == nil {
+		return nil
+	}
+	err := rp.conn.Close()
+	<-rp.done
+	return err
+}
+
+// Misses returns the number of requests that were not in the transcript
+func (rp *Replayer) Misses() int {
+	rp.Lock()
+	defer rp.Unlock()
+	return rp.misses
+}
+
+func (rp *Replayer) serve() {
+	defer close(rp.done)
+	decoder := &gosnmp.GoSNMP{}
+	buf := make([]byte, maxTrapSize)
+	for {
+		n, addr, err := rp.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		req, err := decoder.SnmpDecodePacket(buf[:n])
+		if err != nil {
+			continue
+		}
+		resp, err := rp.respond(req)
+		if err != nil {
+			continue
+		}
+		b, err := resp.MarshalMsg()
+		if err != nil {
+			continue
+		}
+		rp.conn.WriteTo(b, addr)
+	}
+}
+
+// respond builds the response to req from the transcript
+func (rp *Replayer) respond(req *gosnmp.SnmpPacket) (*gosnmp.SnmpPacket, error) {
+	resp := &gosnmp.SnmpPacket{
+		Version:   req.Version,
+		Community: req.Community,
+		PDUType:   gosnmp.GetResponse,
+		RequestID: req.RequestID,
+	}
+	k := requestExchange(req).key()
+
+	rp.Lock()
+	queue, ok := rp.exchanges[k]
+	if !ok {
+		rp.misses++
+		rp.Unlock()
+		for _, pdu := range req.Variables {
+			resp.Variables = append(resp.Variables, gosnmp.SnmpPDU{Name: pdu.Name, Type: gosnmp.NoSuchObject})
+		}
+		return resp, nil
+	}
+	e := queue[0]
+	if len(queue) > 1 {
+		rp.exchanges[k] = queue[1:]
+	}
+	rp.Unlock()
+
+	resp.Error, resp.ErrorIndex = e.Error, e.ErrorIndex
+	for _, r := range e.Response {
+		pdu, err := r.PDU()
+		if err != nil {
+			return nil, err
+		}
+		resp.Variables = append(resp.Variables, pdu)
+	}
+	return resp, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func replayClient(t *testing.T, rp *Replayer) *gosnmp.GoSNMP {
+	addr, err := rp.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := profileV2
+	p.Host, p.Port = "127.0.0.1", addr.Port
+	client, err := NewClient(p, LazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := connected(client); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// ifDescrTranscript is a walk of ifDescr, its last varbind past the column
+var ifDescrTranscript = Exchange{
+	PDUType:        gosnmp.GetBulkRequest,
+	OIDs:           []string{ifDescr},
+	MaxRepetitions: defaultRepetitions,
+	Response: []Record{
+		{OID: ifDescr + ".1", Type: int32(gosnmp.OctetString), BytesVal: []byte("lo")},
+		{OID: ifDescr + ".2", Type: int32(gosnmp.OctetString), BytesVal: []byte("eth0")},
+		{OID: ifOperStatus + ".1", Type: int32(gosnmp.Integer), IntVal: 1},
+	},
+}
+
+// replayer returns a Replayer of the exchanges, closed when the test ends
+func replayer(t *testing.T, exchanges ...Exchange) *Replayer {
+	var transcript bytes.Buffer
+	enc := json.NewEncoder(&transcript)
+	for _, x := range exchanges {
+		if err := enc.Encode(x); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rp, err := NewReplayer(&transcript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rp.Close() })
+	return rp
+}
+
+// replayAgent returns a client of an agent answering with the exchanges,
+// both closed when the test ends
+func replayAgent(t *testing.T, exchanges ...Exchange) *gosnmp.GoSNMP {
+	client := replayClient(t, replayer(t, exchanges...))
+	t.Cleanup(func() { client.Conn.Close() })
+	return client
+}
+
+func TestRecordReplay(t *testing.T) {
+	// a transcript standing in for a capture from a real device
+	agent := replayer(t, Exchange{
+		PDUType:  gosnmp.GetRequest,
+		OIDs:     []string{sysName},
+		Response: []Record{{OID: sysName, Type: int32(gosnmp.OctetString), BytesVal: []byte("router1")}},
+	})
+
+	var transcript bytes.Buffer
+	client := replayClient(t, agent)
+	recorder, err := NewRecorder(client, &transcript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Get(client, []string{sysName}); err != nil {
+		t.Fatal(err)
+	}
+	client.Conn.Close()
+	if err := recorder.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := NewReplayer(&transcript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replay.Close()
+	client = replayClient(t, replay)
+	defer client.Conn.Close()
+	values, _, err := Get(client, []string{sysName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[sysName] != "router1" {
+		t.Errorf("expected router1, got %v", values[sysName])
+	}
+
+	if _, failed, _ := Get(client, []string{sysUpTime}); failed[sysUpTime] == nil {
+		t.Error("expected request not in transcript to fail")
+	}
+	if replay.Misses() != 1 {
+		t.Errorf("expected 1 miss, got %d", replay.Misses())
+	}
+}
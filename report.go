@@ -0,0 +1,63 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+const usmStats = ".1.3.6.1.6.3.15.1.1."
+
+// ErrPrivNotSupported is returned when the agent rejects privacy (AuthPriv),
+// the SecLevel should be downgraded to AuthNoPriv
+var ErrPrivNotSupported = errors.New("agent does not support privacy, try SecLevel AuthNoPriv")
+
+// usmReports describes the SNMPv3 USM report OIDs (RFC 3414)
+var usmReports = map[string]string{
+	usmStats + "1.0": "unsupported security level",
+	usmStats + "2.0": "not in time window",
+	usmStats + "3.0": "unknown user name",
+	usmStats + "4.0": "unknown engine ID",
+	usmStats + "5.0": "wrong digest (bad auth password or protocol)",
+	usmStats + "6.0": "decryption error (bad privacy password or protocol)",
+}
+
+// reportError returns the error for a USM report pdu, or nil if the
+// pdu is not a report
+func reportError(client *gosnmp.GoSNMP, pdu gosnmp.SnmpPDU) error {
+	if client.Version != gosnmp.Version3 || !strings.HasPrefix(pdu.Name, usmStats) {
+		return nil
+	}
+	if pdu.Name == usmStats+"1.0" && client.MsgFlags&gosnmp.AuthPriv == gosnmp.AuthPriv {
+		return errors.Wrapf(ErrPrivNotSupported, "host %s", client.Target)
+	}
+	if reason, ok := usmReports[pdu.Name]; ok {
+		return errors.Errorf("host %s reported: %s", client.Target, reason)
+	}
+	return errors.Errorf("host %s reported: %s", client.Target, pdu.Name)
+}
+
+// packetError returns the error for a response that is a USM report
+func packetError(client *gosnmp.GoSNMP, packet *gosnmp.SnmpPacket) error {
+	if packet.PDUType != gosnmp.Report || len(packet.Variables) == 0 {
+		return nil
+	}
+	return reportError(client, packet.Variables[0])
+}
+
+// probeReport checks if a v3 agent is rejecting requests with a report
+func probeReport(client *gosnmp.GoSNMP) error {
+	if client.Version != gosnmp.Version3 {
+		return nil
+	}
+	packet, err := client.Get([]string{sysUpTime})
+	if err != nil {
+		return err
+	}
+	return packetError(client, packet)
+}
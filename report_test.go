@@ -0,0 +1,32 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+func TestReportError(t *testing.T) {
+	client := &gosnmp.GoSNMP{Target: testHost, Version: gosnmp.Version3, MsgFlags: gosnmp.AuthPriv}
+	packet := &gosnmp.SnmpPacket{
+		PDUType:   gosnmp.Report,
+		Variables: []gosnmp.SnmpPDU{{Name: usmStats + "1.0", Type: gosnmp.Counter32, Value: uint(1)}},
+	}
+	if err := packetError(client, packet); errors.Cause(err) != ErrPrivNotSupported {
+		t.Errorf("expected privacy error, got: %v", err)
+	}
+	client.MsgFlags = gosnmp.AuthNoPriv
+	if err := packetError(client, packet); err == nil || errors.Cause(err) == ErrPrivNotSupported {
+		t.Errorf("expected security level error, got: %v", err)
+	}
+	packet.PDUType = gosnmp.GetResponse
+	packet.Variables[0].Name = sysName
+	if err := packetError(client, packet); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// RetryHook is called after each attempt of a request with the attempt
+// number (starting at 1), how long it took, and its error, if any
+type RetryHook func(attempt int, d time.Duration, err error)
+
+// ErrRetryBudgetExhausted is returned for retries refused by a RetryBudget
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// retryWatch is what is watching the attempts of a client
+type retryWatch struct {
+	hook   RetryHook
+	budget *RetryBudget
+}
+
+func (w retryWatch) empty() bool {
+	return w.hook == nil && w.budget == nil
+}
+
+var (
+	retryWatches = make(map[*gosnmp.GoSNMP]retryWatch)
+	rmu          sync.Mutex
+)
+
+// OnRetry calls hook for every attempt gosnmp makes on behalf of the
+// package helpers, showing how many retries each request needed.
+// Attempts are numbered per request, so a walk answered without loss
+// reports only first attempts; a nil hook removes it.
+func OnRetry(client *gosnmp.GoSNMP, hook RetryHook) {
+	watch(client, func(w *retryWatch) { w.hook = hook })
+}
+
+// LimitRetries makes the client's retries, including those gosnmp
+// makes for each request, draw from budget. A retry refused by the
+// budget fails at once with ErrRetryBudgetExhausted. A nil budget removes it.
+func LimitRetries(client *gosnmp.GoSNMP, budget *RetryBudget) {
+	watch(client, func(w *retryWatch) { w.budget = budget })
+}
+
+// watch updates what is watching the client, wrapping its conn if connected
+func watch(client *gosnmp.GoSNMP, update func(*retryWatch)) {
+	rmu.Lock()
+	w := retryWatches[client]
+	update(&w)
+	if w.empty() {
+		delete(retryWatches, client)
+	} else {
+		retryWatches[client] = w
+	}
+	rmu.Unlock()
+	if rc, ok := client.Conn.(*retryConn); ok {
+		rc.set(w)
+		return
+	}
+	if !w.empty() && client.Conn != nil {
+		client.Conn = &retryConn{Conn: client.Conn, watch: w}
+	}
+}
+
+// watchRetries wraps a newly connected client's conn if it is watched
+func watchRetries(client *gosnmp.GoSNMP) {
+	rmu.Lock()
+	w, ok := retryWatches[client]
+	rmu.Unlock()
+	if ok && client.Conn != nil {
+		client.Conn = &retryConn{Conn: client.Conn, watch: w}
+	}
+}
+
+// endAttempts resets the attempt count once a helper request completes
+func endAttempts(client *gosnmp.GoSNMP) {
+	if rc, ok := client.Conn.(*retryConn); ok {
+		rc.reset()
+	}
+}
+
+// retryConn times each request sent. A write after a response is a new
+// request, one after a failed read is gosnmp retrying the last.
+type retryConn struct {
+	net.Conn
+	sync.Mutex
+	watch    retryWatch
+	attempt  int
+	answered bool
+	start    time.Time
+}
+
+func (r *retryConn) set(w retryWatch) {
+	r.Lock()
+	r.watch = w
+	r.Unlock()
+}
+
+func (r *retryConn) reset() {
+	r.Lock()
+	r.attempt, r.answered = 0, false
+	r.Unlock()
+}
+
+// done reports the outcome of the current attempt
+func (r *retryConn) done(err error) {
+	r.Lock()
+	hook, attempt, d := r.watch.hook, r.attempt, time.Since(r.start)
+	r.Unlock()
+	if hook != nil && attempt > 0 {
+		hook(attempt, d, err)
+	}
+}
+
+func (r *retryConn) Write(b []byte) (int, error) {
+	r.Lock()
+	if r.answered {
+		r.attempt, r.answered = 0, false
+	}
+	r.attempt++
+	r.start = time.Now()
+	retry, budget := r.attempt > 1, r.watch.budget
+	r.Unlock()
+	if retry && budget != nil && !budget.Take() {
+		r.done(ErrRetryBudgetExhausted)
+		return 0, ErrRetryBudgetExhausted
+	}
+	n, err := r.Conn.Write(b)
+	if err != nil {
+		r.done(err)
+	}
+	return n, err
+}
+
+func (r *retryConn) Read(b []byte) (int, error) {
+	n, err := r.Conn.Read(b)
+	r.done(err)
+	if err == nil {
+		r.Lock()
+		r.answered = true
+		r.Unlock()
+	}
+	return n, err
+}
+
+// RetryableFunc decides whether a failed request is worth retrying
+type RetryableFunc func(err error) bool
+
+// DefaultRetryable retries timeouts and temporary network errors, but
+// not errors that will not change on retry such as those reported by
+// a v3 agent (unknown user, wrong digest, etc) or a cancelled context
+func DefaultRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch errors.Cause(err) {
+	case context.Canceled, context.DeadlineExceeded:
+		return false
+	}
+	if nerr, ok := errors.Cause(err).(net.Error); ok {
+		return nerr.Timeout() || nerr.Temporary()
+	}
+	return isTimeout(err)
+}
+
+// RetryOption adjusts the behavior of Retry
+type RetryOption func(*retryOptions)
+
+type retryOptions struct {
+	retryable RetryableFunc
+	backoff   time.Duration
+}
+
+// Retryable sets the func that decides which errors are retried,
+// in place of DefaultRetryable
+func Retryable(fn RetryableFunc) RetryOption {
+	return func(o *retryOptions) {
+		o.retryable = fn
+	}
+}
+
+// RetryBackoff waits d before the first retry, doubling for each one after
+func RetryBackoff(d time.Duration) RetryOption {
+	return func(o *retryOptions) {
+		o.backoff = d
+	}
+}
+
+// Retry calls fn up to tries times, until it succeeds or fails with an
+// error that is not retryable. This is in addition to the retries gosnmp
+// makes for each request; set Profile.Retries to 0 to disable those.
+// The last error is returned, or the context's if it ends first. If the
+// context has a RetryBudget (see WithRetryBudget), retries beyond it are
+// not made.
+func Retry(ctx context.Context, tries int, fn func() error, opts ...RetryOption) error {
+	o := retryOptions{retryable: DefaultRetryable}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if tries < 1 {
+		tries = 1
+	}
+	budget := retryBudget(ctx)
+	backoff := o.backoff
+	var err error
+	for try := 0; try < tries; try++ {
+		if try > 0 && budget != nil && !budget.Take() {
+			return err
+		}
+		if try > 0 && backoff > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			backoff *= 2
+		}
+		if err = fn(); err == nil || !o.retryable(err) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// RetryBudget caps the retries made across many requests, such as
+// those of a poll cycle, so that a partial outage does not multiply
+// into a storm of retries. It is safe for concurrent use.
+type RetryBudget struct {
+	sync.Mutex
+	tokens, size int
+}
+
+// NewRetryBudget returns a budget of n retries
+func NewRetryBudget(n int) *RetryBudget {
+	return &RetryBudget{tokens: n, size: n}
+}
+
+// Take uses one retry from the budget, returning false if none are left
+func (b *RetryBudget) Take() bool {
+	b.Lock()
+	defer b.Unlock()
+	if b.tokens <= 0 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Remaining returns the number of retries left
+func (b *RetryBudget) Remaining() int {
+	b.Lock()
+	defer b.Unlock()
+	return b.tokens
+}
+
+// Refill restores the budget, e.g., at the start of a poll cycle
+func (b *RetryBudget) Refill() {
+	b.Lock()
+	b.tokens = b.size
+	b.Unlock()
+}
+
+type retryBudgetKey struct{}
+
+// WithRetryBudget returns a context whose requests draw their retries
+// from budget, for Retry and for the clients of PollHosts
+func WithRetryBudget(ctx context.Context, budget *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, budget)
+}
+
+// retryBudget returns the context's retry budget, if any
+func retryBudget(ctx context.Context) *RetryBudget {
+	if ctx == nil {
+		return nil
+	}
+	b, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return b
+}
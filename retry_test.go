@@ -0,0 +1,213 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// dropAgent ignores the first drop requests it receives and answers the rest
+func dropAgent(t *testing.T, drop int) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		decoder := &gosnmp.GoSNMP{}
+		buf := make([]byte, maxTrapSize)
+		for n := 0; ; n++ {
+			size, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < drop {
+				continue
+			}
+			req, err := decoder.SnmpDecodePacket(buf[:size])
+			if err != nil {
+				continue
+			}
+			resp := &gosnmp.SnmpPacket{
+				Version:   req.Version,
+				Community: req.Community,
+				PDUType:   gosnmp.GetResponse,
+				RequestID: req.RequestID,
+			}
+			for _, pdu := range req.Variables {
+				resp.Variables = append(resp.Variables, gosnmp.SnmpPDU{Name: pdu.Name, Type: gosnmp.OctetString, Value: []byte("router1")})
+			}
+			b, err := resp.MarshalMsg()
+			if err == nil {
+				conn.WriteTo(b, addr)
+			}
+		}
+	}()
+	return conn
+}
+
+func TestOnRetry(t *testing.T) {
+	agent := dropAgent(t, 2)
+	defer agent.Close()
+	p := profileV2
+	p.Host, p.Port, p.Retries = "127.0.0.1", agent.LocalAddr().(*net.UDPAddr).Port, 3
+	client, err := NewClient(p, LazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Timeout = 50 * time.Millisecond
+
+	var attempts []int
+	var failures int
+	OnRetry(client, func(attempt int, d time.Duration, err error) {
+		attempts = append(attempts, attempt)
+		if err != nil {
+			failures++
+		}
+	})
+	defer OnRetry(client, nil)
+	if _, err := GetScalar(client, sysName); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Conn.Close()
+	if len(attempts) != 3 || attempts[2] != 3 || failures != 2 {
+		t.Errorf("expected 2 failed attempts then success, got %v with %d failures", attempts, failures)
+	}
+
+	attempts = nil
+	if _, err := GetScalar(client, sysName); err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 1 || attempts[0] != 1 {
+		t.Errorf("expected attempts to restart for the next request, got %v", attempts)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), 3, func() error {
+		calls++
+		return testNetError{}
+	})
+	if err == nil || calls != 3 {
+		t.Errorf("expected 3 calls and an error, got %d calls: %v", calls, err)
+	}
+
+	calls = 0
+	fatal := errors.New("host router1 reported: unknown user name")
+	if err := Retry(context.Background(), 3, func() error {
+		calls++
+		return fatal
+	}); err != fatal || calls != 1 {
+		t.Errorf("expected fatal error without retries, got %d calls: %v", calls, err)
+	}
+
+	calls = 0
+	err = Retry(context.Background(), 3, func() error {
+		if calls++; calls < 2 {
+			return fatal
+		}
+		return nil
+	}, Retryable(func(error) bool { return true }), RetryBackoff(time.Millisecond))
+	if err != nil || calls != 2 {
+		t.Errorf("expected success on the second call, got %d calls: %v", calls, err)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	agent := dropAgent(t, 1000)
+	defer agent.Close()
+	p := profileV2
+	p.Host, p.Port, p.Retries = "127.0.0.1", agent.LocalAddr().(*net.UDPAddr).Port, 5
+	client, err := NewClient(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Conn.Close()
+	client.Timeout = 20 * time.Millisecond
+
+	budget := NewRetryBudget(1)
+	LimitRetries(client, budget)
+	defer LimitRetries(client, nil)
+	if _, err := GetScalar(client, sysName); errors.Cause(err) != ErrRetryBudgetExhausted {
+		t.Errorf("expected budget to be exhausted, got %v", err)
+	}
+	if budget.Remaining() != 0 {
+		t.Errorf("expected no retries left, got %d", budget.Remaining())
+	}
+
+	budget.Refill()
+	calls := 0
+	ctx := WithRetryBudget(context.Background(), budget)
+	Retry(ctx, 5, func() error {
+		calls++
+		return testNetError{}
+	})
+	if calls != 2 {
+		t.Errorf("expected 1 retry from the budget, got %d calls", calls)
+	}
+}
+
+// stepAgent answers a walk of ifDescr one value per GETBULK, taking
+// three requests in all
+func stepAgent(t *testing.T) *Replayer {
+	var exchanges []Exchange
+	next := []string{ifDescr + ".1", ifDescr + ".2", ifOperStatus + ".1"}
+	for i, oid := range []string{ifDescr, ifDescr + ".1", ifDescr + ".2"} {
+		exchanges = append(exchanges, Exchange{
+			PDUType:        gosnmp.GetBulkRequest,
+			OIDs:           []string{oid},
+			MaxRepetitions: 1,
+			Response:       []Record{{OID: next[i], Type: int32(gosnmp.OctetString), BytesVal: []byte("eth")}},
+		})
+	}
+	return replayer(t, exchanges...)
+}
+
+func TestRetryBudgetWalk(t *testing.T) {
+	agent := stepAgent(t)
+	client := replayClient(t, agent)
+	defer client.Conn.Close()
+
+	// requests answered first time draw nothing from the budget
+	LimitRetries(client, NewRetryBudget(0))
+	defer LimitRetries(client, nil)
+	values, err := Walk(client, ifDescr, MaxRepetitions(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || agent.Misses() != 0 {
+		t.Errorf("expected 2 values with no misses, got %v with %d misses", values, agent.Misses())
+	}
+}
+
+func TestOnRetryWalk(t *testing.T) {
+	agent := stepAgent(t)
+	client := replayClient(t, agent)
+	defer client.Conn.Close()
+
+	var attempts []int
+	OnRetry(client, func(attempt int, d time.Duration, err error) {
+		attempts = append(attempts, attempt)
+	})
+	defer OnRetry(client, nil)
+	if _, err := Walk(client, ifDescr, MaxRepetitions(1)); err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("expected an attempt per request, got %v", attempts)
+	}
+	for _, attempt := range attempts {
+		if attempt != 1 {
+			t.Errorf("expected no retries without drops, got %v", attempts)
+			break
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+const (
+	ipCidrRouteTable = ".1.3.6.1.2.1.4.24.4"
+	ipRouteTable     = ".1.3.6.1.2.1.4.21"
+)
+
+// ipCidrRouteEntry columns
+const (
+	ipCidrRouteIfIndex = 5
+	ipCidrRouteProto   = 7
+	ipCidrRouteMetric1 = 11
+)
+
+// ipRouteEntry columns
+const (
+	ipRouteIfIndex = 2
+	ipRouteMetric1 = 3
+	ipRouteNextHop = 7
+	ipRouteProto   = 9
+	ipRouteMask    = 11
+)
+
+// Route is an entry of the device's IPv4 routing table
+type Route struct {
+	Dest    net.IPNet
+	NextHop net.IP
+	IfIndex int
+	Proto   int
+	Metric  int
+}
+
+// RouteTable returns the device's routes from the ipCidrRouteTable,
+// or from the deprecated ipRouteTable if the former is empty
+func RouteTable(client *gosnmp.GoSNMP) ([]Route, error) {
+	rows, err := GetTable(client, ipCidrRouteTable)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 {
+		return cidrRoutes(rows)
+	}
+	if rows, err = GetTable(client, ipRouteTable); err != nil {
+		return nil, err
+	}
+	return ipRoutes(rows)
+}
+
+// sortedIndexes returns the row indexes in OID order
+func sortedIndexes(rows map[string]map[int]interface{}) []string {
+	indexes := make([]string, 0, len(rows))
+	for index := range rows {
+		indexes = append(indexes, index)
+	}
+	SortOIDs(indexes)
+	return indexes
+}
+
+// cidrIndex decodes an ipCidrRouteTable index of dest.mask.tos.nexthop
+func cidrIndex(index string) (dest net.IPNet, nextHop net.IP, err error) {
+	ip, rest, err := indexOctets(index, net.IPv4len)
+	if err != nil {
+		return dest, nil, err
+	}
+	mask, rest, err := indexOctets(rest, net.IPv4len)
+	if err != nil {
+		return dest, nil, err
+	}
+	tos := strings.SplitN(rest, ".", 2)
+	if len(tos) < 2 {
+		return dest, nil, errors.Errorf("no next hop in route index %s", index)
+	}
+	if _, err := strconv.Atoi(tos[0]); err != nil {
+		return dest, nil, errors.Errorf("invalid tos in route index %s", index)
+	}
+	hop, _, err := indexOctets(tos[1], net.IPv4len)
+	if err != nil {
+		return dest, nil, err
+	}
+	return net.IPNet{IP: net.IP(ip), Mask: net.IPMask(mask)}, net.IP(hop), nil
+}
+
+func cidrRoutes(rows map[string]map[int]interface{}) ([]Route, error) {
+	routes := make([]Route, 0, len(rows))
+	for _, index := range sortedIndexes(rows) {
+		dest, hop, err := cidrIndex(index)
+		if err != nil {
+			return nil, err
+		}
+		row := rows[index]
+		r := Route{Dest: dest, NextHop: hop}
+		r.IfIndex, _ = row[ipCidrRouteIfIndex].(int)
+		r.Proto, _ = row[ipCidrRouteProto].(int)
+		r.Metric, _ = row[ipCidrRouteMetric1].(int)
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+func ipRoutes(rows map[string]map[int]interface{}) ([]Route, error) {
+	routes := make([]Route, 0, len(rows))
+	for _, index := range sortedIndexes(rows) {
+		ip, _, err := indexOctets(index, net.IPv4len)
+		if err != nil {
+			return nil, err
+		}
+		row := rows[index]
+		r := Route{Dest: net.IPNet{IP: net.IP(ip)}}
+		if s, ok := row[ipRouteMask].(string); ok {
+			if mask := net.ParseIP(s).To4(); mask != nil {
+				r.Dest.Mask = net.IPMask(mask)
+			}
+		}
+		if s, ok := row[ipRouteNextHop].(string); ok {
+			r.NextHop = net.ParseIP(s).To4()
+		}
+		r.IfIndex, _ = row[ipRouteIfIndex].(int)
+		r.Proto, _ = row[ipRouteProto].(int)
+		r.Metric, _ = row[ipRouteMetric1].(int)
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
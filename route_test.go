@@ -0,0 +1,66 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+)
+
+func TestCidrRoutes(t *testing.T) {
+	rows := map[string]map[int]interface{}{
+		"10.1.0.0.255.255.0.0.0.192.168.1.254": {
+			ipCidrRouteIfIndex: 3,
+			ipCidrRouteProto:   13,
+			ipCidrRouteMetric1: 20,
+		},
+		"0.0.0.0.0.0.0.0.0.192.168.1.1": {
+			ipCidrRouteIfIndex: 2,
+			ipCidrRouteProto:   3,
+		},
+	}
+	routes, err := cidrRoutes(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %d", len(routes))
+	}
+	if s := routes[0].Dest.String(); s != "0.0.0.0/0" {
+		t.Errorf("expected default route first, got %s", s)
+	}
+	r := routes[1]
+	if r.Dest.String() != "10.1.0.0/16" || r.NextHop.String() != "192.168.1.254" {
+		t.Errorf("unexpected route: %s via %s", r.Dest.String(), r.NextHop)
+	}
+	if r.IfIndex != 3 || r.Proto != 13 || r.Metric != 20 {
+		t.Errorf("unexpected route columns: %+v", r)
+	}
+	if _, err := cidrRoutes(map[string]map[int]interface{}{"10.1.0.0.255.255.0.0": {}}); err == nil {
+		t.Error("expected error for short index")
+	}
+}
+
+func TestIPRoutes(t *testing.T) {
+	rows := map[string]map[int]interface{}{
+		"172.16.0.0": {
+			ipRouteIfIndex: 4,
+			ipRouteMetric1: 1,
+			ipRouteNextHop: "10.0.0.1",
+			ipRouteProto:   2,
+			ipRouteMask:    "255.240.0.0",
+		},
+	}
+	routes, err := ipRoutes(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := routes[0]
+	if r.Dest.String() != "172.16.0.0/12" || r.NextHop.String() != "10.0.0.1" {
+		t.Errorf("unexpected route: %s via %s", r.Dest.String(), r.NextHop)
+	}
+	if r.IfIndex != 4 || r.Proto != 2 || r.Metric != 1 {
+		t.Errorf("unexpected route columns: %+v", r)
+	}
+}
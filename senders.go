@@ -45,7 +45,7 @@ func counter(value interface{}) (uint64, error) {
 	case int32:
 		return uint64(value.(int32)), nil
 	default:
-		return 0, errors.Errorf("invalid cooked data type:%T value:%v\n", value, value)
+		return 0, errors.Wrapf(ErrTypeMismatch, "invalid cooked data type:%T value:%v", value, value)
 	}
 }
 
@@ -73,7 +73,7 @@ func CalcSender(sender Sender, cook Recipies) Sender {
 			var err error
 			this, err := counter(value)
 			if err != nil {
-				return err
+				return errors.Wrapf(err, "%s (%s)", name, oid)
 			}
 
 			if prior, ok := saved[oid]; ok {
@@ -0,0 +1,142 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// Session serializes access to a client so it can be shared by goroutines.
+// The client connects on first use, and reconnects on the next request
+// after a network error.
+type Session struct {
+	mu      sync.Mutex
+	client  *gosnmp.GoSNMP
+	host    string
+	limiter *TargetLimiter
+	filter  *OIDFilter
+	used    time.Time
+}
+
+// NewSession returns a session for the profile
+func NewSession(p Profile, opts ...ClientOption) (*Session, error) {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	client, err := NewClient(p, append(opts, LazyConnect())...)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{client: client, host: p.Host, limiter: o.limit, filter: o.filter}, nil
+}
+
+// Do runs fn with exclusive use of the connected client. The session's
+// OIDFilter is not applied to requests fn makes; only Get, Walk and Set
+// enforce it.
+func (s *Session) Do(fn func(*gosnmp.GoSNMP) error) error {
+	return s.DoContext(context.Background(), fn)
+}
+
+// DoContext is Do, waiting no longer than ctx allows for the
+// session's target limiter, if any
+func (s *Session) DoContext(ctx context.Context, fn func(*gosnmp.GoSNMP) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if s.limiter != nil {
+		release, err := s.limiter.Acquire(ctx, s.host)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used = time.Now()
+	if err := connected(s.client); err != nil {
+		return err
+	}
+	err := fn(s.client)
+	if _, ok := errors.Cause(err).(net.Error); ok {
+		s.client.Conn.Close()
+		s.client.Conn = nil
+	}
+	return err
+}
+
+// Get returns the values of the given OIDs (see Get). If the session
+// has an OIDFilter, requests for OIDs it denies fail with ErrOIDDenied.
+func (s *Session) Get(oids []string, opts ...GetOption) (values map[string]interface{}, failed map[string]error, err error) {
+	if err := s.filter.checkGet(oids); err != nil {
+		return nil, nil, err
+	}
+	err = s.Do(func(client *gosnmp.GoSNMP) error {
+		var err error
+		values, failed, err = Get(client, oids, opts...)
+		return err
+	})
+	return values, failed, err
+}
+
+// Walk returns the values of the subtree at rootOID (see Walk). If the
+// session has an OIDFilter, the walk is pruned to the allowed subtrees.
+// A rootOID with nothing allowed under it, or whose walk would touch a
+// denied subtree, fails with ErrOIDDenied.
+func (s *Session) Walk(rootOID string, opts ...WalkOption) (values map[string]interface{}, err error) {
+	roots, err := s.filter.walkRoots(rootOID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.Do(func(client *gosnmp.GoSNMP) error {
+		values = make(map[string]interface{})
+		for _, root := range roots {
+			found, err := Walk(client, root, opts...)
+			for oid, v := range found {
+				values[oid] = v
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return values, err
+}
+
+// Set sets oid to value, reading it back to confirm if readBack is true (see SetConfirm)
+func (s *Session) Set(ctx context.Context, oid string, value interface{}, readBack bool) error {
+	if err := s.filter.checkGet([]string{oid}); err != nil {
+		return err
+	}
+	return s.DoContext(ctx, func(client *gosnmp.GoSNMP) error {
+		return SetConfirm(ctx, client, oid, value, readBack)
+	})
+}
+
+// idle returns how long since the session was last used
+func (s *Session) idle() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Since(s.used)
+}
+
+// Close closes the client's connection
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client.Conn == nil {
+		return nil
+	}
+	err := s.client.Conn.Close()
+	s.client.Conn = nil
+	return err
+}
@@ -0,0 +1,59 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+type testNetError struct{}
+
+func (testNetError) Error() string   { return "test network error" }
+func (testNetError) Timeout() bool   { return true }
+func (testNetError) Temporary() bool { return true }
+
+func TestSession(t *testing.T) {
+	s, err := NewSession(profileV2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	count := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Do(func(client *gosnmp.GoSNMP) error {
+				if client.Conn == nil {
+					t.Error("expected client to be connected")
+				}
+				count++
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	if count != 10 {
+		t.Errorf("expected 10 calls, got %d", count)
+	}
+
+	var conn net.Conn
+	s.Do(func(client *gosnmp.GoSNMP) error {
+		conn = client.Conn
+		return testNetError{}
+	})
+	s.Do(func(client *gosnmp.GoSNMP) error {
+		if client.Conn == conn {
+			t.Error("expected client to reconnect after a network error")
+		}
+		return nil
+	})
+}
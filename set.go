@@ -0,0 +1,262 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+var (
+	// ErrSetNotApplied is returned when a value read back does not match what was set
+	ErrSetNotApplied = errors.New("set value was not applied")
+
+	// ErrLockContention is returned by SafeSet when another manager kept changing the lock
+	ErrLockContention = errors.New("TestAndIncr lock is in use by another manager")
+)
+
+// safeSetTries is how many times SafeSet reads the lock and retries the set
+const safeSetTries = 5
+
+// setPDU returns a varbind for value with its SNMP type inferred.
+// A gosnmp.SnmpPDU is passed through as is for explicit typing.
+func setPDU(oid string, value interface{}) (gosnmp.SnmpPDU, error) {
+	pdu := gosnmp.SnmpPDU{Name: oid, Value: value}
+	switch v := value.(type) {
+	case gosnmp.SnmpPDU:
+		v.Name = oid
+		return v, nil
+	case int:
+		pdu.Type = gosnmp.Integer
+	case int32:
+		pdu.Type, pdu.Value = gosnmp.Integer, int(v)
+	case int64:
+		pdu.Type, pdu.Value = gosnmp.Integer, int(v)
+	case uint:
+		pdu.Type, pdu.Value = gosnmp.Gauge32, uint32(v)
+	case uint32:
+		pdu.Type = gosnmp.Gauge32
+	case string, []byte:
+		pdu.Type = gosnmp.OctetString
+	case net.IP:
+		pdu.Type, pdu.Value = gosnmp.IPAddress, v.String()
+	default:
+		return pdu, errors.Errorf("unsupported set type:%T value:%v", value, value)
+	}
+	return pdu, nil
+}
+
+// sameValue compares a value set with the raw value read back
+func sameValue(sent, got interface{}) bool {
+	normal := func(v interface{}) string {
+		switch v := v.(type) {
+		case gosnmp.SnmpPDU:
+			return fmt.Sprint(v.Value)
+		case []byte:
+			return string(v)
+		case net.IP:
+			return v.String()
+		}
+		return fmt.Sprint(v)
+	}
+	return normal(sent) == normal(got)
+}
+
+// callContext is a client's Context within withContext: the caller's,
+// with the values kept in the client's own Context (e.g., its walk
+// strategy) still visible
+type callContext struct {
+	context.Context
+	base context.Context // the client's Context, restored after
+}
+
+func (c *callContext) Value(key interface{}) interface{} {
+	if v := c.Context.Value(key); v != nil {
+		return v
+	}
+	if c.base == nil {
+		return nil
+	}
+	return c.base.Value(key)
+}
+
+// setClientValue keeps a value in the client's own Context, where it
+// outlasts any withContext in progress
+func setClientValue(client *gosnmp.GoSNMP, key, value interface{}) {
+	client.Context = withClientValue(client.Context, key, value)
+}
+
+func withClientValue(ctx context.Context, key, value interface{}) context.Context {
+	if cc, ok := ctx.(*callContext); ok {
+		cc.base = withClientValue(cc.base, key, value)
+		return cc
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, key, value)
+}
+
+// withContext runs fn with ctx applied to the client's requests
+func withContext(ctx context.Context, client *gosnmp.GoSNMP, fn func() error) error {
+	if ctx == nil {
+		return fn()
+	}
+	cc := &callContext{Context: ctx, base: client.Context}
+	client.Context = cc
+	defer func() { client.Context = cc.base }()
+	return fn()
+}
+
+// SetConfirm sets oid to value and, if readBack is true, gets the
+// oid again to confirm the device applied it
+func SetConfirm(ctx context.Context, client *gosnmp.GoSNMP, oid string, value interface{}, readBack bool) error {
+	var equal func(sent, got interface{}) bool
+	if readBack {
+		equal = sameValue
+	}
+	return SetConfirmFunc(ctx, client, oid, value, equal)
+}
+
+// SetConfirmFunc sets oid to value and, unless equal is nil, reads it
+// back and compares it using equal. This allows for devices that
+// normalize the value set (e.g., enums).
+func SetConfirmFunc(ctx context.Context, client *gosnmp.GoSNMP, oid string, value interface{}, equal func(sent, got interface{}) bool) error {
+	oid, err := getOID(oid)
+	if err != nil {
+		return err
+	}
+	pdu, err := setPDU(oid, value)
+	if err != nil {
+		return err
+	}
+	if err := connected(client); err != nil {
+		return err
+	}
+	return withContext(ctx, client, func() error {
+		packet, err := client.Set([]gosnmp.SnmpPDU{pdu})
+		if err != nil {
+			return errors.Wrapf(err, "set %s failed", oid)
+		}
+		if packet.Error != gosnmp.NoError {
+			return errors.Errorf("set %s failed: %s", oid, packet.Error)
+		}
+		if equal == nil {
+			return nil
+		}
+		packet, err = client.Get([]string{oid})
+		if err != nil {
+			return errors.Wrapf(err, "read back of %s failed", oid)
+		}
+		if len(packet.Variables) != 1 || !equal(value, packet.Variables[0].Value) {
+			return ErrSetNotApplied
+		}
+		return nil
+	})
+}
+
+// SafeSet sets oid to value guarded by the TestAndIncr spin lock at lockOID
+// (RFC 2579). The lock's current value is read and sent back with the new
+// value in a single SET, which the agent applies only if the lock is
+// unchanged (incrementing it as it does). If another manager took the
+// lock in between, the agent responds inconsistentValue and the set is
+// retried with the lock read again.
+func SafeSet(ctx context.Context, client *gosnmp.GoSNMP, lockOID, oid string, value interface{}) error {
+	lockOID, err := getOID(lockOID)
+	if err != nil {
+		return err
+	}
+	if oid, err = getOID(oid); err != nil {
+		return err
+	}
+	pdu, err := setPDU(oid, value)
+	if err != nil {
+		return err
+	}
+	if err := connected(client); err != nil {
+		return err
+	}
+	return withContext(ctx, client, func() error {
+		for i := 0; i < safeSetTries; i++ {
+			packet, err := client.Get([]string{lockOID})
+			if err != nil {
+				return errors.Wrapf(err, "read of lock %s failed", lockOID)
+			}
+			if len(packet.Variables) != 1 {
+				return errors.Errorf("no value for lock %s", lockOID)
+			}
+			lock, ok := packet.Variables[0].Value.(int)
+			if !ok || packet.Variables[0].Type != gosnmp.Integer {
+				return errors.Errorf("lock %s is not a TestAndIncr: %v", lockOID, packet.Variables[0].Value)
+			}
+			spin := gosnmp.SnmpPDU{Name: lockOID, Type: gosnmp.Integer, Value: lock}
+			packet, err = client.Set([]gosnmp.SnmpPDU{spin, pdu})
+			if err != nil {
+				return errors.Wrapf(err, "set %s failed", oid)
+			}
+			switch packet.Error {
+			case gosnmp.NoError:
+				return nil
+			case gosnmp.InconsistentValue:
+				continue
+			}
+			return errors.Errorf("set %s failed: %s", oid, packet.Error)
+		}
+		return ErrLockContention
+	})
+}
+
+// SetError is returned by SetMulti when the agent rejects the SET,
+// identifying the varbind at fault by its error-index
+type SetError struct {
+	Status gosnmp.SNMPError
+	Index  int    // 1 based position of the rejected varbind, 0 if not given
+	OID    string // OID of the rejected varbind, if known
+}
+
+func (e *SetError) Error() string {
+	if len(e.OID) > 0 {
+		return fmt.Sprintf("set failed: %s for varbind %d (%s)", e.Status, e.Index, e.OID)
+	}
+	return fmt.Sprintf("set failed: %s", e.Status)
+}
+
+// SetMulti sets all of the varbinds in a single SET, which the agent
+// applies together or not at all. Names are resolved as by the other
+// helpers. If the agent rejects the SET a *SetError is returned.
+func SetMulti(client *gosnmp.GoSNMP, pdus []gosnmp.SnmpPDU) error {
+	vars := make([]gosnmp.SnmpPDU, len(pdus))
+	for i, pdu := range pdus {
+		oid, err := getOID(pdu.Name)
+		if err != nil {
+			return err
+		}
+		pdu.Name = oid
+		vars[i] = pdu
+	}
+	if err := connected(client); err != nil {
+		return err
+	}
+	packet, err := client.Set(vars)
+	if err == nil {
+		err = packetError(client, packet)
+	}
+	recordStats(client, err)
+	if err != nil {
+		return errors.Wrap(err, "set failed")
+	}
+	if packet.Error == gosnmp.NoError {
+		return nil
+	}
+	serr := &SetError{Status: packet.Error, Index: int(packet.ErrorIndex)}
+	if serr.Index > 0 && serr.Index <= len(vars) {
+		serr.OID = vars[serr.Index-1].Name
+	}
+	return serr
+}
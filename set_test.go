@@ -0,0 +1,108 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"net"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestSetPDU(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		kind  gosnmp.Asn1BER
+	}{
+		{1, gosnmp.Integer},
+		{int64(2), gosnmp.Integer},
+		{uint32(3), gosnmp.Gauge32},
+		{"descr", gosnmp.OctetString},
+		{net.ParseIP("10.0.0.1"), gosnmp.IPAddress},
+	}
+	for _, test := range tests {
+		pdu, err := setPDU(sysName, test.value)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if pdu.Type != test.kind {
+			t.Errorf("value %v: expected type %s, got %s", test.value, test.kind, pdu.Type)
+		}
+	}
+	if _, err := setPDU(sysName, 1.5); err == nil {
+		t.Error("expected error for float value")
+	}
+}
+
+func TestSameValue(t *testing.T) {
+	if !sameValue("router", []byte("router")) {
+		t.Error("expected string to match octets")
+	}
+	if !sameValue(uint32(7), uint(7)) {
+		t.Error("expected gauge values to match")
+	}
+	if sameValue(1, 2) {
+		t.Error("expected different values to not match")
+	}
+}
+
+func TestSafeSet(t *testing.T) {
+	const (
+		lock     = ".1.3.6.1.6.3.1.1.6.1.0" // snmpSetSerialNo
+		location = ".1.3.6.1.2.1.1.6.0"
+	)
+	lockValue := func(v int64) []Record {
+		return []Record{{OID: lock, Type: int32(gosnmp.Integer), IntVal: v}}
+	}
+	set := append(lockValue(6), Record{OID: location, Type: int32(gosnmp.OctetString), BytesVal: []byte("lab")})
+	agent := replayer(t,
+		Exchange{PDUType: gosnmp.GetRequest, OIDs: []string{lock}, Response: lockValue(5)},
+		Exchange{PDUType: gosnmp.GetRequest, OIDs: []string{lock}, Response: lockValue(6)},
+		// another manager took the lock before the first set
+		Exchange{PDUType: gosnmp.SetRequest, OIDs: []string{lock, location}, Error: gosnmp.InconsistentValue, ErrorIndex: 1, Response: set},
+		Exchange{PDUType: gosnmp.SetRequest, OIDs: []string{lock, location}, Response: set},
+	)
+	client := replayClient(t, agent)
+	defer client.Conn.Close()
+
+	if err := SafeSet(nil, client, lock, location, "lab"); err != nil {
+		t.Fatal(err)
+	}
+	if agent.Misses() != 0 {
+		t.Errorf("expected all requests to be in the transcript, got %d misses", agent.Misses())
+	}
+}
+
+func TestSetMulti(t *testing.T) {
+	const (
+		alias       = ".1.3.6.1.2.1.31.1.1.1.18.3"
+		adminStatus = ".1.3.6.1.2.1.2.2.1.7.3"
+	)
+	pdus := []gosnmp.SnmpPDU{
+		{Name: alias, Type: gosnmp.OctetString, Value: []byte("uplink")},
+		{Name: adminStatus, Type: gosnmp.Integer, Value: 7},
+	}
+	response := []Record{
+		{OID: alias, Type: int32(gosnmp.OctetString), BytesVal: []byte("uplink")},
+		{OID: adminStatus, Type: int32(gosnmp.Integer), IntVal: 7},
+	}
+	client := replayAgent(t,
+		Exchange{PDUType: gosnmp.SetRequest, OIDs: []string{alias, adminStatus}, Error: gosnmp.WrongValue, ErrorIndex: 2, Response: response},
+		Exchange{PDUType: gosnmp.SetRequest, OIDs: []string{alias, adminStatus}, Response: response},
+	)
+
+	err := SetMulti(client, pdus)
+	serr, ok := err.(*SetError)
+	if !ok {
+		t.Fatalf("expected a SetError, got %v", err)
+	}
+	if serr.Status != gosnmp.WrongValue || serr.Index != 2 || serr.OID != adminStatus {
+		t.Errorf("unexpected set error: %+v", serr)
+	}
+	if err := SetMulti(client, pdus); err != nil {
+		t.Fatal(err)
+	}
+}
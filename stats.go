@@ -0,0 +1,172 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+var (
+	clientStats = make(map[*gosnmp.GoSNMP]*ClientStats)
+	smu         sync.Mutex
+)
+
+// ClientStats tracks the requests made through the package helpers for a client
+type ClientStats struct {
+	sync.Mutex
+	requests, timeouts, errors int
+	lastErr                    error
+	lastErrTime                time.Time
+	sizes                      ResponseSizes
+}
+
+// ResponseSizes summarizes the responses received during bulk walks,
+// showing whether a device's max-repetitions could be raised (small
+// responses) or is near the MTU (large ones)
+type ResponseSizes struct {
+	Responses int
+	Varbinds  int
+	Bytes     int // approximate decoded size
+	MaxBytes  int // of the largest response
+}
+
+// AvgVarbinds returns the mean number of varbinds per response
+func (r ResponseSizes) AvgVarbinds() float64 {
+	if r.Responses == 0 {
+		return 0
+	}
+	return float64(r.Varbinds) / float64(r.Responses)
+}
+
+// AvgBytes returns the mean approximate size of a response
+func (r ResponseSizes) AvgBytes() float64 {
+	if r.Responses == 0 {
+		return 0
+	}
+	return float64(r.Bytes) / float64(r.Responses)
+}
+
+// AttachStats starts tracking stats for the client, returning
+// the existing stats if already attached
+func AttachStats(client *gosnmp.GoSNMP) *ClientStats {
+	smu.Lock()
+	defer smu.Unlock()
+	s, ok := clientStats[client]
+	if !ok {
+		s = &ClientStats{}
+		clientStats[client] = s
+	}
+	return s
+}
+
+// DetachStats stops tracking stats for the client
+func DetachStats(client *gosnmp.GoSNMP) {
+	smu.Lock()
+	delete(clientStats, client)
+	smu.Unlock()
+}
+
+// recordStats notes the outcome of a request if the client is being
+// tracked, and reconciles the engine cache and clock with the client
+func recordStats(client *gosnmp.GoSNMP, err error) {
+	endAttempts(client)
+	if checkEngine(client) {
+		logf("cached engine for %s is stale, rediscovering", client.Target)
+	} else if err == nil {
+		noteEngineClock(client)
+	}
+	smu.Lock()
+	s, ok := clientStats[client]
+	smu.Unlock()
+	if ok {
+		s.record(err)
+	}
+}
+
+// recordResponse notes the size of a walk response if the client is being tracked
+func recordResponse(client *gosnmp.GoSNMP, packet *gosnmp.SnmpPacket) {
+	smu.Lock()
+	s, ok := clientStats[client]
+	smu.Unlock()
+	if !ok || packet == nil {
+		return
+	}
+	size := 0
+	for _, pdu := range packet.Variables {
+		size += pduSize(pdu)
+	}
+	s.Lock()
+	s.sizes.Responses++
+	s.sizes.Varbinds += len(packet.Variables)
+	s.sizes.Bytes += size
+	if size > s.sizes.MaxBytes {
+		s.sizes.MaxBytes = size
+	}
+	s.Unlock()
+}
+
+func (s *ClientStats) record(err error) {
+	s.Lock()
+	defer s.Unlock()
+	s.requests++
+	if err == nil {
+		return
+	}
+	s.errors++
+	if isTimeout(err) {
+		s.timeouts++
+	}
+	s.lastErr = err
+	s.lastErrTime = time.Now()
+}
+
+// Requests returns the total number of requests made
+func (s *ClientStats) Requests() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.requests
+}
+
+// Errors returns the number of requests that failed
+func (s *ClientStats) Errors() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.errors
+}
+
+// Timeouts returns the number of requests that timed out
+func (s *ClientStats) Timeouts() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.timeouts
+}
+
+// ResponseSizes returns the sizes of the bulk walk responses received
+func (s *ClientStats) ResponseSizes() ResponseSizes {
+	s.Lock()
+	defer s.Unlock()
+	return s.sizes
+}
+
+// LastError returns when the most recent error occurred and the error
+func (s *ClientStats) LastError() (time.Time, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.lastErrTime, s.lastErr
+}
+
+// isTimeout reports whether err is due to the agent not responding
+func isTimeout(err error) bool {
+	if nerr, ok := errors.Cause(err).(net.Error); ok && nerr.Timeout() {
+		return true
+	}
+	return strings.Contains(err.Error(), "timeout")
+}
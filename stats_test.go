@@ -0,0 +1,50 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+func TestClientStats(t *testing.T) {
+	client := &gosnmp.GoSNMP{Target: testHost}
+	stats := AttachStats(client)
+	defer DetachStats(client)
+	if AttachStats(client) != stats {
+		t.Fatal("expected existing stats to be returned")
+	}
+
+	recordStats(client, nil)
+	recordStats(client, errors.New("request timeout (after 1 retries)"))
+	recordStats(client, errors.New("unknown user name"))
+
+	if stats.Requests() != 3 || stats.Errors() != 2 || stats.Timeouts() != 1 {
+		t.Errorf("unexpected stats: %d requests %d errors %d timeouts", stats.Requests(), stats.Errors(), stats.Timeouts())
+	}
+	if when, err := stats.LastError(); err == nil || when.IsZero() {
+		t.Error("expected last error to be recorded")
+	}
+}
+
+func TestResponseSizes(t *testing.T) {
+	client := replayAgent(t, ifDescrTranscript)
+	stats := AttachStats(client)
+	defer DetachStats(client)
+
+	if _, err := Walk(client, ifDescr); err != nil {
+		t.Fatal(err)
+	}
+	// the walk ends with the response that steps past ifDescr
+	sizes := stats.ResponseSizes()
+	if sizes.Responses != 1 || sizes.Varbinds != 3 || sizes.AvgVarbinds() != 3 {
+		t.Errorf("unexpected response sizes: %+v", sizes)
+	}
+	if sizes.MaxBytes == 0 || sizes.MaxBytes != sizes.Bytes {
+		t.Errorf("expected the only response to be the largest: %+v", sizes)
+	}
+}
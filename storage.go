@@ -0,0 +1,63 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"strconv"
+
+	"github.com/soniah/gosnmp"
+)
+
+const hrStorageTable = ".1.3.6.1.2.1.25.2.3"
+
+// hrStorageEntry columns
+const (
+	hrStorageType            = 2
+	hrStorageDescr           = 3
+	hrStorageAllocationUnits = 4
+	hrStorageSize            = 5
+	hrStorageUsed            = 6
+)
+
+// StorageEntry is an entry of the hrStorageTable (disks, memory, etc)
+type StorageEntry struct {
+	Index      int
+	Descr      string
+	Type       string // OID, e.g., hrStorageFixedDisk
+	TotalBytes uint64
+	UsedBytes  uint64
+}
+
+// StorageTable returns the host's storage, with sizes in bytes
+func StorageTable(client *gosnmp.GoSNMP) ([]StorageEntry, error) {
+	rows, err := GetTable(client, hrStorageTable)
+	if err != nil {
+		return nil, err
+	}
+	return storageEntries(rows), nil
+}
+
+// storageUnits returns an Integer32 storage value as unsigned, as agents
+// with large disks commonly overflow into negative values
+func storageUnits(v interface{}) uint64 {
+	n, _ := v.(int)
+	return uint64(uint32(n))
+}
+
+func storageEntries(rows map[string]map[int]interface{}) []StorageEntry {
+	entries := make([]StorageEntry, 0, len(rows))
+	for _, index := range sortedIndexes(rows) {
+		row := rows[index]
+		e := StorageEntry{}
+		e.Index, _ = strconv.Atoi(index)
+		e.Descr, _ = row[hrStorageDescr].(string)
+		e.Type, _ = row[hrStorageType].(string)
+		units := storageUnits(row[hrStorageAllocationUnits])
+		e.TotalBytes = storageUnits(row[hrStorageSize]) * units
+		e.UsedBytes = storageUnits(row[hrStorageUsed]) * units
+		entries = append(entries, e)
+	}
+	return entries
+}
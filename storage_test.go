@@ -0,0 +1,38 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+)
+
+func TestStorageEntries(t *testing.T) {
+	rows := map[string]map[int]interface{}{
+		"1": {
+			hrStorageType:            ".1.3.6.1.2.1.25.2.1.2",
+			hrStorageDescr:           "Physical memory",
+			hrStorageAllocationUnits: 1024,
+			hrStorageSize:            16384,
+			hrStorageUsed:            4096,
+		},
+		"31": {
+			hrStorageType:            ".1.3.6.1.2.1.25.2.1.4",
+			hrStorageDescr:           "/",
+			hrStorageAllocationUnits: 4096,
+			hrStorageSize:            -1,
+			hrStorageUsed:            1,
+		},
+	}
+	entries := storageEntries(rows)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.Index != 1 || e.Descr != "Physical memory" || e.TotalBytes != 16<<20 || e.UsedBytes != 4<<20 {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e := entries[1]; e.TotalBytes != (1<<32-1)*4096 || e.UsedBytes != 4096 {
+		t.Errorf("expected overflowed size to be unsigned: %+v", e)
+	}
+}
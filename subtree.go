@@ -0,0 +1,82 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// WalkSubtrees walks each of the roots in turn, returning their values
+// keyed by root as given. A gosnmp client is not safe for concurrent
+// use, so the walks share the client sequentially; see
+// WalkSubtreesParallel to walk them at once.
+//
+// If a walk fails the values walked so far are returned with the error.
+func WalkSubtrees(client *gosnmp.GoSNMP, roots []string, opts ...WalkOption) (map[string]map[string]interface{}, error) {
+	results := make(map[string]map[string]interface{}, len(roots))
+	for _, root := range roots {
+		values, err := Walk(client, root, opts...)
+		results[root] = values
+		if err != nil {
+			return results, errors.Wrapf(err, "walk %s", root)
+		}
+	}
+	return results, nil
+}
+
+// WalkSubtreesParallel walks the roots with up to parallel walks at a
+// time, each using its own client made from the profile, so that no
+// client is shared between goroutines. The device must tolerate the
+// extra sessions, and for v3 each client does its own engine discovery
+// unless an engine cache is set (see SetEngineCache).
+//
+// All roots are walked; the first error is returned with the values
+// of the roots that were walked.
+func WalkSubtreesParallel(p Profile, roots []string, parallel int, opts ...WalkOption) (map[string]map[string]interface{}, error) {
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make(map[string]map[string]interface{}, len(roots))
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	queue := make(chan string)
+	for i := 0; i < parallel && i < len(roots); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, cerr := NewClient(p, LazyConnect())
+			for root := range queue {
+				var values map[string]interface{}
+				err := cerr
+				if err == nil {
+					values, err = Walk(client, root, opts...)
+				}
+				mu.Lock()
+				if values != nil {
+					results[root] = values
+				}
+				if err != nil && firstErr == nil {
+					firstErr = errors.Wrapf(err, "walk %s", root)
+				}
+				mu.Unlock()
+			}
+			if cerr == nil && client.Conn != nil {
+				client.Conn.Close()
+			}
+		}()
+	}
+	for _, root := range roots {
+		queue <- root
+	}
+	close(queue)
+	wg.Wait()
+	return results, firstErr
+}
@@ -0,0 +1,57 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"net"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestWalkSubtrees(t *testing.T) {
+	rp := replayer(t,
+		Exchange{
+			PDUType:        gosnmp.GetBulkRequest,
+			OIDs:           []string{ifDescr},
+			MaxRepetitions: defaultRepetitions,
+			Response: []Record{
+				{OID: ifDescr + ".1", Type: int32(gosnmp.OctetString), BytesVal: []byte("lo")},
+				{OID: ifOperStatus + ".1", Type: int32(gosnmp.Integer), IntVal: 1},
+			},
+		},
+		Exchange{
+			PDUType:        gosnmp.GetBulkRequest,
+			OIDs:           []string{ifName},
+			MaxRepetitions: defaultRepetitions,
+			Response: []Record{
+				{OID: ifName + ".1", Type: int32(gosnmp.OctetString), BytesVal: []byte("lo0")},
+				{OID: ifName + ".2", Type: int32(gosnmp.OctetString), BytesVal: []byte("eth0")},
+				{OID: ifAlias + ".1", Type: int32(gosnmp.OctetString), BytesVal: []byte("loopback")},
+			},
+		},
+	)
+	client := replayClient(t, rp)
+	defer client.Conn.Close()
+
+	roots := []string{ifDescr, ifName}
+	results, err := WalkSubtrees(client, roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results[ifDescr]) != 1 || len(results[ifName]) != 2 {
+		t.Errorf("unexpected results: %v", results)
+	}
+
+	p := profileV2
+	p.Host, p.Port = "127.0.0.1", rp.conn.LocalAddr().(*net.UDPAddr).Port
+	results, err = WalkSubtreesParallel(p, roots, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[ifName][ifName+".2"] != "eth0" || results[ifDescr][ifDescr+".1"] != "lo" {
+		t.Errorf("unexpected parallel results: %v", results)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+const (
+	sysORID      = ".1.3.6.1.2.1.1.9.1.2"
+	hrSystemDate = ".1.3.6.1.2.1.25.1.2.0"
+)
+
+// SupportedMIBs returns the capabilities advertised in the device's sysORTable.
+// OIDs are translated to their names if the MIBs have been loaded.
+func SupportedMIBs(client *gosnmp.GoSNMP) ([]string, error) {
+	mibs := []string{}
+	fn := func(pdu gosnmp.SnmpPDU) error {
+		if pdu.Type != gosnmp.ObjectIdentifier {
+			return errors.Errorf("unexpected type for %s: %x", pdu.Name, pdu.Type)
+		}
+		oid := pdu.Value.(string)
+		if name, ok := oidName(oid); ok {
+			oid = name
+		}
+		mibs = append(mibs, oid)
+		return nil
+	}
+	return mibs, walkFunc(client)(sysORID, fn)
+}
+
+// SystemDate returns the device's current date and time (hrSystemDate)
+func SystemDate(client *gosnmp.GoSNMP) (time.Time, error) {
+	if err := connected(client); err != nil {
+		return time.Time{}, err
+	}
+	packet, err := client.Get([]string{hrSystemDate})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(packet.Variables) != 1 || packet.Variables[0].Type != gosnmp.OctetString {
+		return time.Time{}, errors.Errorf("no system date for host %s", client.Target)
+	}
+	t, err := dateTime(packet.Variables[0])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.(time.Time), nil
+}
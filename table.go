@@ -0,0 +1,284 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// how many rows to examine when inferring a table's shape
+const tableSample = 16
+
+// IndexPart is the inferred type of a component of a table index
+type IndexPart int
+
+// Index component types
+const (
+	IndexInteger IndexPart = iota
+	IndexString            // length prefixed octets
+	IndexIPv4              // four octets
+)
+
+func (i IndexPart) String() string {
+	switch i {
+	case IndexString:
+		return "string"
+	case IndexIPv4:
+		return "ipv4"
+	}
+	return "integer"
+}
+
+// TableShape describes the layout of a table's columns and index
+type TableShape struct {
+	Columns   []int       // column numbers found in the sample
+	Arity     int         // subidentifiers in the index (0 if it varies)
+	Composite bool        // index is made of more than one subidentifier
+	Parts     []IndexPart // inferred index components of the first row
+}
+
+// tableEntry returns the OID of the conceptual row of a table
+func tableEntry(tableOID string) (string, error) {
+	oid, err := getOID(tableOID)
+	if err != nil {
+		return oid, err
+	}
+	return oid + ".1", nil
+}
+
+// splitColumn splits the portion of an OID following the table entry
+// into its column number and index
+func splitColumn(entry, oid string) (int, string, bool) {
+	if !strings.HasPrefix(oid, entry+".") {
+		return 0, "", false
+	}
+	rest := oid[len(entry)+1:]
+	dot := strings.Index(rest, ".")
+	if dot < 1 {
+		return 0, "", false
+	}
+	col, err := strconv.Atoi(rest[:dot])
+	if err != nil {
+		return 0, "", false
+	}
+	return col, rest[dot+1:], true
+}
+
+// decodeColumn decodes the value of a table column, as a time.Time if the
+// loaded MIBs declare the column a DateAndTime. Values that are not valid
+// dates (e.g., all zeros for "unset") are decoded as usual.
+func decodeColumn(entry string, col int, pdu gosnmp.SnmpPDU, decode func(gosnmp.SnmpPDU) (interface{}, error)) (interface{}, error) {
+	if info, ok := oidBase[entry+"."+strconv.Itoa(col)]; ok && info.DateAndTime && pdu.Type == gosnmp.OctetString {
+		if b, ok := pdu.Value.([]byte); ok {
+			if t, err := DecodeDateAndTime(b); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return decode(pdu)
+}
+
+// GetTable walks the table and returns its values keyed by index, then column number
+func GetTable(client *gosnmp.GoSNMP, tableOID string) (map[string]map[int]interface{}, error) {
+	return getTable(client, tableOID, pduType)
+}
+
+// getTable walks the table, converting each value with decode
+func getTable(client *gosnmp.GoSNMP, tableOID string, decode func(gosnmp.SnmpPDU) (interface{}, error)) (map[string]map[int]interface{}, error) {
+	entry, err := tableEntry(tableOID)
+	if err != nil {
+		return nil, err
+	}
+	rows := make(map[string]map[int]interface{})
+	fn := func(pdu gosnmp.SnmpPDU) error {
+		col, index, ok := splitColumn(entry, pdu.Name)
+		if !ok {
+			return nil
+		}
+		value, err := decodeColumn(entry, col, pdu, decode)
+		if err != nil {
+			return errors.Wrapf(err, "table column %s", pdu.Name)
+		}
+		row, ok := rows[index]
+		if !ok {
+			row = make(map[int]interface{})
+			rows[index] = row
+		}
+		row[col] = value
+		return nil
+	}
+	return rows, walkFunc(client)(entry, fn)
+}
+
+// MergeByIndex joins per-column {index: value} results, as from separate
+// column walks, into rows of {index: {column: value}}
+func MergeByIndex(columns map[string]map[string]interface{}) map[string]map[string]interface{} {
+	rows := make(map[string]map[string]interface{})
+	for column, values := range columns {
+		for index, value := range values {
+			row, ok := rows[index]
+			if !ok {
+				row = make(map[string]interface{})
+				rows[index] = row
+			}
+			row[column] = value
+		}
+	}
+	return rows
+}
+
+// InspectTable reads a small sample of the table and infers its
+// columns and the structure of its index. The sample is the first rows
+// of the first column, in one GETBULK (or a GETNEXT per row for SNMP
+// v1), and the columns are found with a GETNEXT for each.
+func InspectTable(client *gosnmp.GoSNMP, tableOID string) (TableShape, error) {
+	var shape TableShape
+	entry, err := tableEntry(tableOID)
+	if err != nil {
+		return shape, err
+	}
+	if err := connected(client); err != nil {
+		return shape, err
+	}
+
+	first, indexes, err := sampleRows(client, entry)
+	if err != nil {
+		return shape, err
+	}
+	if len(indexes) == 0 {
+		return shape, errors.Errorf("no rows found for table %s", tableOID)
+	}
+	columns := map[int]struct{}{first: {}}
+	for col := first; ; {
+		next, err := firstUnder(client, entry+"."+strconv.Itoa(col+1))
+		if err != nil {
+			return shape, err
+		}
+		c, _, ok := splitColumn(entry, next)
+		if !ok || c <= col {
+			break
+		}
+		col = c
+		columns[col] = struct{}{}
+	}
+
+	for col := range columns {
+		shape.Columns = append(shape.Columns, col)
+	}
+	sort.Ints(shape.Columns)
+
+	shape.Arity = len(indexes[0])
+	for _, index := range indexes[1:] {
+		if len(index) != shape.Arity {
+			shape.Arity = 0
+			break
+		}
+	}
+	shape.Composite = len(indexes[0]) > 1
+	shape.Parts = indexParts(indexes, shape.Arity > 0)
+	return shape, nil
+}
+
+// sampleRows returns the first column of the entry and the indexes of
+// up to tableSample of its rows
+func sampleRows(client *gosnmp.GoSNMP, entry string) (int, [][]int, error) {
+	var names []string
+	if useBulk(client) {
+		packet, err := client.GetBulk([]string{entry}, 0, tableSample)
+		if err == nil {
+			err = packetError(client, packet)
+		}
+		recordStats(client, err)
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, pdu := range packet.Variables {
+			switch pdu.Type {
+			case gosnmp.EndOfMibView, gosnmp.NoSuchObject, gosnmp.NoSuchInstance:
+				continue
+			}
+			names = append(names, pdu.Name)
+		}
+	} else {
+		for oid := entry; len(names) < tableSample; {
+			next, err := firstUnder(client, oid)
+			if err != nil {
+				return 0, nil, err
+			}
+			if !strings.HasPrefix(next, entry+".") {
+				break
+			}
+			names = append(names, next)
+			oid = next
+		}
+	}
+
+	first := -1
+	var indexes [][]int
+	for _, name := range names {
+		col, index, ok := splitColumn(entry, name)
+		if !ok || (first >= 0 && col != first) {
+			break
+		}
+		first = col
+		indexes = append(indexes, Octets(index))
+	}
+	return first, indexes, nil
+}
+
+// indexParts infers the components of the first index of the sample.
+// This is a best effort: runs of four octet sized values are only taken
+// as addresses when the index length is fixed, and a short unaligned
+// remainder is assumed to start with an integer (e.g. ifIndex.ipaddr).
+func indexParts(indexes [][]int, fixed bool) []IndexPart {
+	first := indexes[0]
+	octets := func(pos, n int) bool {
+		for _, index := range indexes {
+			if pos+n > len(index) {
+				return false
+			}
+			for _, v := range index[pos : pos+n] {
+				if v < 0 || v > 255 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	printable := func(pos, n int) bool {
+		for _, v := range first[pos : pos+n] {
+			if v < 0x20 || v > 0x7e {
+				return false
+			}
+		}
+		return true
+	}
+
+	aligned := func(remain int) bool {
+		return remain%4 == 0 || remain >= 8
+	}
+
+	parts := []IndexPart{}
+	for i := 0; i < len(first); {
+		n := first[i]
+		switch {
+		case n > 0 && i+n < len(first) && printable(i+1, n):
+			parts = append(parts, IndexString)
+			i += n + 1
+		case fixed && aligned(len(first)-i) && octets(i, 4):
+			parts = append(parts, IndexIPv4)
+			i += 4
+		default:
+			parts = append(parts, IndexInteger)
+			i++
+		}
+	}
+	return parts
+}
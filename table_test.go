@@ -0,0 +1,135 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestIndexParts(t *testing.T) {
+	tests := []struct {
+		indexes [][]int
+		fixed   bool
+		parts   []IndexPart
+	}{
+		{[][]int{{1}, {2}}, true, []IndexPart{IndexInteger}},
+		{[][]int{{3, 65, 66, 67, 7}}, false, []IndexPart{IndexString, IndexInteger}},
+		{
+			[][]int{{10, 0, 0, 0, 255, 0, 0, 0, 0, 10, 0, 0, 1}},
+			true,
+			[]IndexPart{IndexIPv4, IndexIPv4, IndexInteger, IndexIPv4},
+		},
+		{[][]int{{10, 0, 0, 1}, {1000, 0, 0, 1}}, true, []IndexPart{IndexInteger, IndexInteger, IndexInteger, IndexInteger}},
+	}
+	for _, test := range tests {
+		got := indexParts(test.indexes, test.fixed)
+		if !reflect.DeepEqual(got, test.parts) {
+			t.Errorf("index %v: expected %v, got %v", test.indexes, test.parts, got)
+		}
+	}
+}
+
+func TestSplitColumn(t *testing.T) {
+	entry := ".1.3.6.1.2.1.2.2.1"
+	col, index, ok := splitColumn(entry, entry+".10.42")
+	if !ok || col != 10 || index != "42" {
+		t.Errorf("unexpected split: %d %s %v", col, index, ok)
+	}
+	if _, _, ok := splitColumn(entry, ".1.3.6.1.2.1.2.3.1"); ok {
+		t.Error("expected OID outside of table to be rejected")
+	}
+}
+
+func TestMergeByIndex(t *testing.T) {
+	columns := map[string]map[string]interface{}{
+		"ifDescr": {"1": "lo", "2": "eth0"},
+		"ifMtu":   {"1": 65536, "2": 1500},
+		"ifAlias": {"2": "uplink"},
+		"ifEmpty": {},
+	}
+	expect := map[string]map[string]interface{}{
+		"1": {"ifDescr": "lo", "ifMtu": 65536},
+		"2": {"ifDescr": "eth0", "ifMtu": 1500, "ifAlias": "uplink"},
+	}
+	if got := MergeByIndex(columns); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+}
+
+func TestDateAndTimeColumn(t *testing.T) {
+	const (
+		table     = ".1.3.6.1.2.1.25.6.3"
+		entry     = table + ".1"
+		installed = entry + ".5"
+	)
+	oidReader(MibInfo{Name: "HOST-RESOURCES-MIB::hrSWInstalledDate", OID: installed, Syntax: "DateAndTime"})
+	defer func() {
+		delete(oidBase, installed)
+		delete(lookupOID, "hrSWInstalledDate")
+	}()
+
+	date := []byte{0x07, 0xe0, 10, 14, 13, 30, 15, 0}
+	client := replayAgent(t, Exchange{
+		PDUType:        gosnmp.GetBulkRequest,
+		OIDs:           []string{entry},
+		MaxRepetitions: defaultRepetitions,
+		Response: []Record{
+			{OID: entry + ".2.1", Type: int32(gosnmp.OctetString), BytesVal: []byte("bash")},
+			{OID: installed + ".1", Type: int32(gosnmp.OctetString), BytesVal: date},
+			{OID: installed + ".2", Type: int32(gosnmp.OctetString), BytesVal: make([]byte, 8)},
+		},
+	})
+
+	rows, err := GetTable(client, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := rows["1"][5].(time.Time); !ok || !got.Equal(time.Date(2016, 10, 14, 13, 30, 15, 0, time.UTC)) {
+		t.Errorf("expected a decoded date, got %v", rows["1"][5])
+	}
+	if got := rows["1"][2]; got != "bash" {
+		t.Errorf("expected other columns as usual, got %v", got)
+	}
+	if _, ok := rows["2"][5].(time.Time); ok {
+		t.Errorf("expected an unset date to not be decoded, got %v", rows["2"][5])
+	}
+}
+
+func TestInspectTable(t *testing.T) {
+	const entry = ".1.3.6.1.2.1.2.2.1"
+	client := replayAgent(t,
+		Exchange{
+			PDUType:        gosnmp.GetBulkRequest,
+			OIDs:           []string{entry},
+			MaxRepetitions: tableSample,
+			Response: []Record{
+				{OID: entry + ".1.1", Type: int32(gosnmp.Integer), IntVal: 1},
+				{OID: entry + ".1.2", Type: int32(gosnmp.Integer), IntVal: 2},
+				{OID: entry + ".2.1", Type: int32(gosnmp.OctetString), BytesVal: []byte("lo")},
+			},
+		},
+		Exchange{
+			PDUType:  gosnmp.GetNextRequest,
+			OIDs:     []string{entry + ".2"},
+			Response: []Record{{OID: entry + ".2.1", Type: int32(gosnmp.OctetString), BytesVal: []byte("lo")}},
+		},
+		Exchange{
+			PDUType:  gosnmp.GetNextRequest,
+			OIDs:     []string{entry + ".3"},
+			Response: []Record{{OID: ".1.3.6.1.2.1.3.1.1.1.1", Type: int32(gosnmp.Integer), IntVal: 1}},
+		},
+	)
+	shape, err := InspectTable(client, ".1.3.6.1.2.1.2.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(shape.Columns, []int{1, 2}) || shape.Arity != 1 {
+		t.Errorf("unexpected shape: %+v", shape)
+	}
+}
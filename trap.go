@@ -0,0 +1,146 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+const maxTrapSize = 65535
+
+// TrapHandler is called with each trap received
+type TrapHandler func(packet *gosnmp.SnmpPacket, addr *net.UDPAddr)
+
+// TrapListener receives traps on a UDP socket it owns, so the
+// socket can be tuned (e.g., a larger read buffer for trap storms)
+type TrapListener struct {
+	sync.Mutex
+	conn     *net.UDPConn
+	params   *gosnmp.GoSNMP
+	handler  TrapHandler
+	received int
+	invalid  int
+	closed   bool
+}
+
+// NewTrapListener binds addr for receiving traps, decoding them according
+// to params (e.g., community or v3 security parameters)
+func NewTrapListener(addr string, params *gosnmp.GoSNMP, handler TrapHandler) (*TrapListener, error) {
+	if params == nil {
+		params = &gosnmp.GoSNMP{}
+	}
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolve %s", addr)
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listen %s", addr)
+	}
+	return &TrapListener{conn: conn, params: params, handler: handler}, nil
+}
+
+// Addr returns the local address traps are received on
+func (t *TrapListener) Addr() *net.UDPAddr {
+	return t.conn.LocalAddr().(*net.UDPAddr)
+}
+
+// SetReadBuffer sets the size of the socket's receive buffer in bytes.
+// The OS may cap this (e.g., net.core.rmem_max on Linux).
+func (t *TrapListener) SetReadBuffer(bytes int) error {
+	return errors.Wrap(t.conn.SetReadBuffer(bytes), "set read buffer")
+}
+
+// Serve reads traps and passes them to the handler until the listener is closed
+func (t *TrapListener) Serve() error {
+	buf := make([]byte, maxTrapSize)
+	for {
+		n, remote, err := t.conn.ReadFromUDP(buf)
+		if err != nil {
+			t.Lock()
+			closed := t.closed
+			t.Unlock()
+			if closed {
+				return nil
+			}
+			return errors.Wrap(err, "trap read")
+		}
+		// decoded values alias the bytes, which handlers may keep
+		packet := t.params.UnmarshalTrap(copyBytes(buf[:n]))
+		t.Lock()
+		t.received++
+		if packet == nil {
+			t.invalid++
+		}
+		t.Unlock()
+		if packet != nil && t.handler != nil {
+			t.handler(packet, remote)
+		}
+	}
+}
+
+// Close stops the listener
+func (t *TrapListener) Close() error {
+	t.Lock()
+	t.closed = true
+	t.Unlock()
+	return t.conn.Close()
+}
+
+// Received returns the number of packets read and how many of those could not be decoded
+func (t *TrapListener) Received() (received, invalid int) {
+	t.Lock()
+	defer t.Unlock()
+	return t.received, t.invalid
+}
+
+// Dropped returns the number of packets the OS dropped for the socket
+// because its buffer was full. This is only available on Linux.
+func (t *TrapListener) Dropped() (int, error) {
+	addr := t.Addr()
+	name := "/proc/net/udp"
+	if addr.IP.To4() == nil && len(addr.IP) > 0 {
+		name = "/proc/net/udp6"
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, errors.Wrap(err, "socket drops are unavailable")
+	}
+	defer f.Close()
+	return udpDrops(f, addr.Port)
+}
+
+// udpDrops finds the drop count for the socket bound to port in /proc/net/udp format
+func udpDrops(r io.Reader, port int) (int, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 13 {
+			continue
+		}
+		colon := strings.LastIndex(fields[1], ":")
+		if colon < 0 {
+			continue
+		}
+		p, err := strconv.ParseInt(fields[1][colon+1:], 16, 32)
+		if err != nil || int(p) != port {
+			continue
+		}
+		return strconv.Atoi(fields[len(fields)-1])
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.Errorf("no socket found for port %d", port)
+}
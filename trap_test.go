@@ -0,0 +1,103 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestTrapListener(t *testing.T) {
+	params := &gosnmp.GoSNMP{Version: gosnmp.Version2c, Community: "public"}
+	got := make(chan *gosnmp.SnmpPacket, 2)
+	l, err := NewTrapListener("127.0.0.1:0", params, func(p *gosnmp.SnmpPacket, _ *net.UDPAddr) {
+		got <- p
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SetReadBuffer(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- l.Serve() }()
+
+	sender := &gosnmp.GoSNMP{
+		Target:    "127.0.0.1",
+		Port:      uint16(l.Addr().Port),
+		Version:   gosnmp.Version2c,
+		Community: "public",
+		Timeout:   time.Second,
+	}
+	if err := sender.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Conn.Close()
+	trap := gosnmp.SnmpTrap{
+		Variables: []gosnmp.SnmpPDU{{Name: sysName, Type: gosnmp.OctetString, Value: "test"}},
+	}
+	if _, err := sender.SendTrap(trap); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case p := <-got:
+		if len(p.Variables) != 2 {
+			t.Errorf("expected 2 varbinds, got %d", len(p.Variables))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("trap not received")
+	}
+
+	// a trap kept by the handler is not overwritten by the next
+	var kept *gosnmp.SnmpPacket
+	for _, name := range []string{"first", "other"} {
+		trap.Variables[0].Value = name
+		if _, err := sender.SendTrap(trap); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case p := <-got:
+			if kept == nil {
+				kept = p
+			}
+		case <-time.After(time.Second):
+			t.Fatal("trap not received")
+		}
+	}
+	if v := string(kept.Variables[1].Value.([]byte)); v != "first" {
+		t.Errorf("expected the kept trap to be unchanged, got %q", v)
+	}
+	if received, invalid := l.Received(); received != 3 || invalid != 0 {
+		t.Errorf("expected 3 received and 0 invalid, got %d and %d", received, invalid)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUDPDrops(t *testing.T) {
+	const proc = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  123: 00000000:00A2 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 12345 2 0000000000000000 17
+  456: 0100007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 12346 2 0000000000000000 0
+`
+	drops, err := udpDrops(strings.NewReader(proc), 162)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drops != 17 {
+		t.Errorf("expected 17 drops, got %d", drops)
+	}
+	if _, err := udpDrops(strings.NewReader(proc), 161); err == nil {
+		t.Error("expected error for unbound port")
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// ErrTypeMismatch is returned when an agent answers with a value of a
+// different type than was asked for, e.g., an OctetString for a counter.
+// The error it is wrapped in names the OID and the type received.
+var ErrTypeMismatch = errors.New("unexpected value type")
+
+// typeMismatch reports the OID and actual type of a wrong-type varbind
+func typeMismatch(pdu gosnmp.SnmpPDU, want string) error {
+	return errors.Wrapf(ErrTypeMismatch, "%s is %s (%T), not %s", pdu.Name, pdu.Type, pdu.Value, want)
+}
+
+// getTyped gets the value of oid, requiring it to be one of types
+func getTyped(client *gosnmp.GoSNMP, oid, want string, types ...gosnmp.Asn1BER) (gosnmp.SnmpPDU, error) {
+	oid, err := getOID(oid)
+	if err != nil {
+		return gosnmp.SnmpPDU{}, err
+	}
+	pdu, err := getPDU(client, oid)
+	if err != nil {
+		return pdu, err
+	}
+	for _, t := range types {
+		if pdu.Type == t {
+			return pdu, nil
+		}
+	}
+	return pdu, typeMismatch(pdu, want)
+}
+
+// GetCounter returns the value of a Counter32 or Counter64 instance
+// (e.g., "ifHCInOctets.3"), or ErrTypeMismatch if it is of another type
+func GetCounter(client *gosnmp.GoSNMP, oid string) (uint64, error) {
+	pdu, err := getTyped(client, oid, "a counter", gosnmp.Counter32, gosnmp.Counter64)
+	if err != nil {
+		return 0, err
+	}
+	switch v := pdu.Value.(type) {
+	case uint:
+		return uint64(v), nil
+	case uint32:
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	}
+	return 0, typeMismatch(pdu, "a counter")
+}
+
+// GetInteger returns the value of an Integer instance (e.g., "ifOperStatus.3"),
+// or ErrTypeMismatch if it is of another type
+func GetInteger(client *gosnmp.GoSNMP, oid string) (int, error) {
+	pdu, err := getTyped(client, oid, "an integer", gosnmp.Integer)
+	if err != nil {
+		return 0, err
+	}
+	v, ok := pdu.Value.(int)
+	if !ok {
+		return 0, typeMismatch(pdu, "an integer")
+	}
+	return v, nil
+}
+
+// GetString returns the cleaned value of an OctetString instance,
+// or ErrTypeMismatch if it is of another type
+func GetString(client *gosnmp.GoSNMP, oid string) (string, error) {
+	pdu, err := getTyped(client, oid, "a string", gosnmp.OctetString)
+	if err != nil {
+		return "", err
+	}
+	b, ok := pdu.Value.([]byte)
+	if !ok {
+		return "", typeMismatch(pdu, "a string")
+	}
+	return cleanString(b), nil
+}
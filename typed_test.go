@@ -0,0 +1,69 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+func TestTypeMismatch(t *testing.T) {
+	inOctets := ifHCInOctets + ".3"
+	oper := ifOperStatus + ".3"
+	client := replayAgent(t,
+		// a buggy agent answering with the wrong types
+		Exchange{PDUType: gosnmp.GetRequest, OIDs: []string{inOctets}, Response: []Record{{OID: inOctets, Type: int32(gosnmp.OctetString), BytesVal: []byte("12345")}}},
+		Exchange{PDUType: gosnmp.GetRequest, OIDs: []string{oper}, Response: []Record{{OID: oper, Type: int32(gosnmp.Counter32), IntVal: 1}}},
+		Exchange{PDUType: gosnmp.GetRequest, OIDs: []string{sysName}, Response: []Record{{OID: sysName, Type: int32(gosnmp.Integer), IntVal: 7}}},
+		Exchange{PDUType: gosnmp.GetRequest, OIDs: []string{sysUpTime}, Response: []Record{{OID: sysUpTime, Type: int32(gosnmp.Counter64), IntVal: 42}}},
+	)
+
+	check := func(err error, oid, actual string) {
+		t.Helper()
+		if errors.Cause(err) != ErrTypeMismatch {
+			t.Errorf("expected type mismatch for %s, got %v", oid, err)
+			return
+		}
+		if !strings.Contains(err.Error(), oid) || !strings.Contains(err.Error(), actual) {
+			t.Errorf("error does not name the OID and type: %v", err)
+		}
+	}
+	_, err := GetCounter(client, inOctets)
+	check(err, inOctets, "OctetString")
+	_, err = GetInteger(client, oper)
+	check(err, oper, "Counter32")
+	_, err = GetString(client, sysName)
+	check(err, sysName, "Integer")
+
+	n, err := GetCounter(client, sysUpTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 42 {
+		t.Errorf("expected 42, got %d", n)
+	}
+
+	// decoders given an OctetString type without octets
+	pdu := gosnmp.SnmpPDU{Name: sysName, Type: gosnmp.OctetString, Value: 7}
+	if _, err := pduType(pdu); errors.Cause(err) != ErrTypeMismatch {
+		t.Errorf("expected type mismatch decoding, got %v", err)
+	}
+	if _, err := bitFormatter(map[int]string{0: "a"})(pdu); errors.Cause(err) != ErrTypeMismatch {
+		t.Errorf("expected type mismatch formatting bits, got %v", err)
+	}
+	pdu = gosnmp.SnmpPDU{Name: oper, Type: gosnmp.Integer, Value: []byte("up")}
+	if _, err := intFormatter(map[int]string{1: "up"})(pdu); errors.Cause(err) != ErrTypeMismatch {
+		t.Errorf("expected type mismatch formatting, got %v", err)
+	}
+
+	sender := CalcSender(func(string, map[string]string, interface{}, TimeStamp) error { return nil }, Recipies{"ifHCInOctets": {Rate: true}})
+	err = sender("ifHCInOctets", map[string]string{"oid": inOctets}, "12345", TimeStamp{})
+	if errors.Cause(err) != ErrTypeMismatch {
+		t.Errorf("expected type mismatch from rate calculation, got %v", err)
+	}
+}
@@ -6,7 +6,11 @@ package snmputil
 
 import (
 	"bytes"
+	"encoding/binary"
+	"math"
+	"net"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -36,6 +40,73 @@ func Octets(in string) []int {
 	return words
 }
 
+// CompareOIDs returns -1, 0 or 1 as OID a sorts before, with or after b
+// in the standard order, numerically by subidentifier. It is the order
+// walks require unless changed by OIDOrder.
+func CompareOIDs(a, b string) int {
+	return compareOIDs(a, b)
+}
+
+// compareOIDs compares dotted OIDs numerically by subidentifier,
+// returning -1, 0 or 1 if a is less than, equal to, or greater than b
+func compareOIDs(a, b string) int {
+	x := Octets(strings.TrimPrefix(a, "."))
+	y := Octets(strings.TrimPrefix(b, "."))
+	for i := 0; i < len(x) && i < len(y); i++ {
+		switch {
+		case x[i] < y[i]:
+			return -1
+		case x[i] > y[i]:
+			return 1
+		}
+	}
+	switch {
+	case len(x) < len(y):
+		return -1
+	case len(x) > len(y):
+		return 1
+	}
+	return 0
+}
+
+// SortOIDs sorts dotted OIDs in numeric order by subidentifier,
+// e.g., .1.3.6.1.2.1.2 sorts before .1.3.6.1.2.1.10
+func SortOIDs(oids []string) {
+	sort.Slice(oids, func(i, j int) bool {
+		return compareOIDs(oids[i], oids[j]) < 0
+	})
+}
+
+// normalOID ensures an OID has a leading dot
+func normalOID(oid string) string {
+	if strings.HasPrefix(oid, ".") {
+		return oid
+	}
+	return "." + oid
+}
+
+// NextOID returns the lexicographic successor of oid, i.e., its first possible child
+func NextOID(oid string) string {
+	return normalOID(oid) + ".0"
+}
+
+// NextSibling returns the first OID following the subtree of oid
+func NextSibling(oid string) string {
+	oid = normalOID(oid)
+	dot := strings.LastIndex(oid, ".")
+	last, err := strconv.ParseUint(oid[dot+1:], 10, 32)
+	if err != nil {
+		return NextOID(oid)
+	}
+	return oid[:dot+1] + strconv.FormatUint(last+1, 10)
+}
+
+// WithinSubtree reports whether oid is root or one of its descendants
+func WithinSubtree(root, oid string) bool {
+	root, oid = normalOID(root), normalOID(oid)
+	return oid == root || strings.HasPrefix(oid, strings.TrimSuffix(root, ".")+".")
+}
+
 // oidStrings converts ascii octets into an array of words
 func oidStrings(in string) []string {
 	words := []string{}
@@ -67,27 +138,67 @@ func cleanString(in []byte) string {
 	return string(acc)
 }
 
-// dateTime converts snmp datetime octets into time.Time
-func dateTime(pdu gosnmp.SnmpPDU) (interface{}, error) {
-	d := pdu.Value.([]byte)
+// DecodeMAC converts an octet string value (e.g., ifPhysAddress) to a
+// hardware address. EUI-48, EUI-64 and InfiniBand lengths are accepted.
+func DecodeMAC(value interface{}) (net.HardwareAddr, error) {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return nil, errors.Errorf("invalid MAC address type:%T", value)
+	}
+	switch len(b) {
+	case 6, 8, 20:
+		return net.HardwareAddr(append([]byte{}, b...)), nil
+	}
+	return nil, errors.Errorf("invalid MAC address length:%d", len(b))
+}
+
+// DecodeDateAndTime decodes a DateAndTime textual convention (RFC 2579):
+// 8 octets of local time, or 11 including the direction and hours and
+// minutes from UTC. Times without an offset are returned as UTC.
+func DecodeDateAndTime(d []byte) (time.Time, error) {
 	offset := 0
 	switch len(d) {
 	case 8:
 	case 11:
 		offset = (int(d[9]) * 3600) + (int(d[10]) * 60)
-		if string(d[8]) == "-" {
+		switch d[8] {
+		case '+':
+		case '-':
 			offset = -offset
+		default:
+			return time.Time{}, errors.Errorf("invalid direction from UTC: %q", d[8])
 		}
 	default:
 		return time.Time{}, errors.Errorf("invalid octet length:%d", len(d))
 	}
 	year := int(d[0])<<8 + int(d[1])
 	month := time.Month(d[2])
-	nano := int(d[7]) * 1024
-	loc := time.FixedZone("UTC", offset)
+	if month < time.January || month > time.December || d[3] < 1 || d[3] > 31 || d[4] > 23 || d[5] > 59 || d[6] > 60 || d[7] > 9 {
+		return time.Time{}, errors.Errorf("invalid date and time: %x", d)
+	}
+	// last octet is in deci-seconds
+	nano := int(d[7]) * int(100*time.Millisecond)
+	loc := time.UTC
+	if offset != 0 {
+		loc = time.FixedZone("", offset)
+	}
 	return time.Date(year, month, int(d[3]), int(d[4]), int(d[5]), int(d[6]), nano, loc), nil
 }
 
+// dateTime converts snmp datetime octets into time.Time
+func dateTime(pdu gosnmp.SnmpPDU) (interface{}, error) {
+	d, ok := pdu.Value.([]byte)
+	if !ok {
+		return time.Time{}, errors.Errorf("invalid datetime type:%T", pdu.Value)
+	}
+	return DecodeDateAndTime(d)
+}
+
 // pduType verifies and normalizes the pdu data
 func pduType(pdu gosnmp.SnmpPDU) (interface{}, error) {
 	switch pdu.Type {
@@ -130,7 +241,11 @@ func pduType(pdu gosnmp.SnmpPDU) (interface{}, error) {
 			return pdu.Value, errors.Errorf("invalid Counter64 type:%T pdu.Value:%v\n", pdu.Value, pdu.Value)
 		}
 	case gosnmp.OctetString:
-		s := cleanString([]byte(pdu.Value.([]uint8)))
+		b, ok := pdu.Value.([]byte)
+		if !ok {
+			return pdu.Value, typeMismatch(pdu, "octets")
+		}
+		s := cleanString(b)
 
 		// sometimes numbers are encoded as strings
 		if f, err := strconv.ParseFloat(s, 64); err == nil {
@@ -140,12 +255,51 @@ func pduType(pdu gosnmp.SnmpPDU) (interface{}, error) {
 			return i, nil
 		}
 		return s, nil
+	case gosnmp.Null, gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView:
+		return pdu.Value, errors.Errorf("no value for %s, type: %x\n", pdu.Name, pdu.Type)
 	default:
-		return pdu.Value, errors.Errorf("unsupported type: %x (%T), pdu.Value: %v\n", pdu.Type, pdu.Value, pdu.Value)
+		return rawValue(pdu), nil
 	}
 	return pdu.Value, nil
 }
 
+// RawValue holds a value of a type that isn't decoded, e.g., Opaque
+// wrapped floats or vendor specific types, so callers can decode it
+type RawValue struct {
+	Type  gosnmp.Asn1BER
+	Bytes []byte
+}
+
+// Float returns the value of an Opaque float or double
+func (r RawValue) Float() (float64, error) {
+	switch {
+	case r.Type == gosnmp.OpaqueFloat && len(r.Bytes) == 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(r.Bytes))), nil
+	case r.Type == gosnmp.OpaqueDouble && len(r.Bytes) == 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(r.Bytes)), nil
+	}
+	return 0, errors.Errorf("type %x is not a float", r.Type)
+}
+
+// rawValue preserves a value of an undecoded type. gosnmp decodes Opaque
+// floats, so those are re-encoded to their original bytes.
+func rawValue(pdu gosnmp.SnmpPDU) RawValue {
+	r := RawValue{Type: pdu.Type}
+	switch v := pdu.Value.(type) {
+	case float32:
+		r.Bytes = make([]byte, 4)
+		binary.BigEndian.PutUint32(r.Bytes, math.Float32bits(v))
+	case float64:
+		r.Bytes = make([]byte, 8)
+		binary.BigEndian.PutUint64(r.Bytes, math.Float64bits(v))
+	case []byte:
+		r.Bytes = v
+	case string:
+		r.Bytes = []byte(v)
+	}
+	return r
+}
+
 // getOID returns the OID representing name
 func getOID(oid string) (string, error) {
 	if strings.HasPrefix(oid, ".") {
@@ -0,0 +1,153 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestCompareOIDs(t *testing.T) {
+	tests := []struct {
+		a, b string
+		cmp  int
+	}{
+		{".1.3.6.1.2.1.2", ".1.3.6.1.2.1.10", -1},
+		{".1.3.6.1.2.1.10", ".1.3.6.1.2.1.2", 1},
+		{".1.3.6.1", "1.3.6.1", 0},
+		{".1.3.6", ".1.3.6.1", -1},
+	}
+	for _, test := range tests {
+		if got := compareOIDs(test.a, test.b); got != test.cmp {
+			t.Errorf("compare %s to %s: expected %d, got %d", test.a, test.b, test.cmp, got)
+		}
+	}
+}
+
+func TestNextOID(t *testing.T) {
+	if got := NextOID("1.3.6.1.2.1.1"); got != ".1.3.6.1.2.1.1.0" {
+		t.Errorf("unexpected next oid: %s", got)
+	}
+	if got := NextSibling(".1.3.6.1.2.1.1.9"); got != ".1.3.6.1.2.1.1.10" {
+		t.Errorf("unexpected next sibling: %s", got)
+	}
+	if compareOIDs(NextOID(".1.3.6"), ".1.3.6") <= 0 || compareOIDs(NextSibling(".1.3.6"), ".1.3.6.99") <= 0 {
+		t.Error("expected successors to sort after the original")
+	}
+}
+
+func TestWithinSubtree(t *testing.T) {
+	tests := []struct {
+		root, oid string
+		within    bool
+	}{
+		{".1.3.6.1.2.1.2", ".1.3.6.1.2.1.2.2.1.1.1", true},
+		{"1.3.6.1.2.1.2", ".1.3.6.1.2.1.2", true},
+		{".1.3.6.1.2.1.2", ".1.3.6.1.2.1.25.1", false},
+		{".1.3.6.1.2.1.2", ".1.3.6.1.2.1", false},
+	}
+	for _, test := range tests {
+		if got := WithinSubtree(test.root, test.oid); got != test.within {
+			t.Errorf("%s within %s: expected %v", test.oid, test.root, test.within)
+		}
+	}
+}
+
+func TestDateTime(t *testing.T) {
+	// 2016-10-14 13:30:15.5 -04:00
+	b := []byte{0x07, 0xe0, 10, 14, 13, 30, 15, 5, '-', 4, 0}
+	v, err := dateTime(gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := v.(time.Time)
+	expected := time.Date(2016, 10, 14, 17, 30, 15, 500000000, time.UTC)
+	if !got.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+	if _, err := dateTime(gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: b[:8]}); err != nil {
+		t.Error(err)
+	}
+	if _, err := dateTime(gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: b[:5]}); err == nil {
+		t.Error("expected error for short datetime")
+	}
+}
+
+func TestDecodeDateAndTime(t *testing.T) {
+	b := []byte{0x07, 0xe0, 10, 14, 13, 30, 15, 5, '+', 5, 30}
+	got, err := DecodeDateAndTime(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2016, 10, 14, 8, 0, 15, 500000000, time.UTC); !got.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+	if _, offset := got.Zone(); offset != 5*3600+30*60 {
+		t.Errorf("expected offset +05:30, got %d", offset)
+	}
+	got, err = DecodeDateAndTime(b[:8])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Location() != time.UTC || got.Hour() != 13 {
+		t.Errorf("expected local time as UTC, got %s", got)
+	}
+	for _, bad := range [][]byte{
+		{0x07, 0xe0, 10, 14, 13, 30, 15, 5, '*', 5, 30},
+		{0x07, 0xe0, 13, 14, 13, 30, 15, 5},
+		make([]byte, 8),
+		b[:10],
+	} {
+		if _, err := DecodeDateAndTime(bad); err == nil {
+			t.Errorf("expected error for %x", bad)
+		}
+	}
+}
+
+func TestDecodeMAC(t *testing.T) {
+	mac, err := DecodeMAC([]byte{0x00, 0x1b, 0x21, 0x3a, 0x4f, 0x5e})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mac.String() != "00:1b:21:3a:4f:5e" {
+		t.Errorf("unexpected MAC: %s", mac)
+	}
+	if _, err := DecodeMAC([]byte{1, 2, 3}); err == nil {
+		t.Error("expected error for short MAC")
+	}
+	if _, err := DecodeMAC(42); err == nil {
+		t.Error("expected error for integer MAC")
+	}
+}
+
+func TestRawValue(t *testing.T) {
+	v, err := pduType(gosnmp.SnmpPDU{Type: gosnmp.OpaqueFloat, Value: float32(1.5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, ok := v.(RawValue)
+	if !ok {
+		t.Fatalf("expected RawValue, got %T", v)
+	}
+	if raw.Type != gosnmp.OpaqueFloat || string(raw.Bytes) != "\x3f\xc0\x00\x00" {
+		t.Errorf("unexpected raw value: %x %x", raw.Type, raw.Bytes)
+	}
+	if f, err := raw.Float(); err != nil || f != 1.5 {
+		t.Errorf("expected 1.5, got %v %v", f, err)
+	}
+
+	v, _ = pduType(gosnmp.SnmpPDU{Type: gosnmp.Opaque, Value: []byte{1, 2}})
+	if raw, ok := v.(RawValue); !ok || string(raw.Bytes) != "\x01\x02" {
+		t.Errorf("expected opaque bytes to be preserved, got %#v", v)
+	}
+	if _, err := raw.Float(); err != nil {
+		t.Error(err)
+	}
+	if _, err := (RawValue{Type: gosnmp.Opaque}).Float(); err == nil {
+		t.Error("expected error for non-float")
+	}
+}
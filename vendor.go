@@ -0,0 +1,64 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"strconv"
+	"strings"
+)
+
+// enterprises is the private enterprise arc (1.3.6.1.4.1)
+const enterprises = "1.3.6.1.4.1."
+
+// vendors are common network vendors from the IANA Private Enterprise Numbers
+var vendors = map[int]string{
+	2:     "IBM",
+	9:     "Cisco",
+	11:    "HP",
+	43:    "3Com",
+	171:   "D-Link",
+	311:   "Microsoft",
+	674:   "Dell",
+	1588:  "Brocade",
+	1916:  "Extreme Networks",
+	1991:  "Foundry Networks",
+	2011:  "Huawei",
+	2636:  "Juniper Networks",
+	3375:  "F5 Networks",
+	4526:  "Netgear",
+	6027:  "Force10 Networks",
+	6486:  "Alcatel",
+	6876:  "VMware",
+	8072:  "Net-SNMP",
+	11863: "TP-Link",
+	12356: "Fortinet",
+	14179: "Airespace (Cisco)",
+	14823: "Aruba",
+	14988: "MikroTik",
+	25461: "Palo Alto Networks",
+	30065: "Arista Networks",
+}
+
+// VendorFromSysObjectID returns the enterprise number of a sysObjectID
+// value (e.g., 9 for ".1.3.6.1.4.1.9.1.1208"), or false if the OID is
+// not under the private enterprises arc
+func VendorFromSysObjectID(oid string) (int, bool) {
+	oid = strings.TrimPrefix(oid, ".")
+	if !strings.HasPrefix(oid, enterprises) {
+		return 0, false
+	}
+	arc := strings.SplitN(oid[len(enterprises):], ".", 2)[0]
+	n, err := strconv.Atoi(arc)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// VendorName returns the name of a well known enterprise number
+func VendorName(enterprise int) (string, bool) {
+	name, ok := vendors[enterprise]
+	return name, ok
+}
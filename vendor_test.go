@@ -0,0 +1,33 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+)
+
+func TestVendorFromSysObjectID(t *testing.T) {
+	for oid, want := range map[string]int{
+		".1.3.6.1.4.1.9.1.1208":  9,
+		"1.3.6.1.4.1.2636.1.1.1": 2636,
+		".1.3.6.1.4.1.8072":      8072,
+	} {
+		n, ok := VendorFromSysObjectID(oid)
+		if !ok || n != want {
+			t.Errorf("%s: expected %d, got %d (%t)", oid, want, n, ok)
+		}
+	}
+	for _, oid := range []string{".1.3.6.1.2.1.1", ".1.3.6.1.4.1.", ".1.3.6.1.4.1.x.1", ".1.3.6.1.4.10.1"} {
+		if _, ok := VendorFromSysObjectID(oid); ok {
+			t.Errorf("%s: expected not to be an enterprise OID", oid)
+		}
+	}
+	if name, ok := VendorName(9); !ok || name != "Cisco" {
+		t.Errorf("expected Cisco, got %q", name)
+	}
+	if _, ok := VendorName(99999999); ok {
+		t.Error("expected unknown enterprise")
+	}
+}
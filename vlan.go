@@ -0,0 +1,51 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// VLANProfile returns the profile for reading per-VLAN data (e.g., the
+// bridge tables) from Cisco style agents. For v1/v2c the community is
+// indexed as "community@vlan", for v3 the context name is "vlan-N".
+func VLANProfile(p Profile, vlan int) (Profile, error) {
+	if vlan < 1 || vlan > 4094 {
+		return p, errors.Errorf("invalid vlan: %d", vlan)
+	}
+	id := strconv.Itoa(vlan)
+	if p.Version == "3" {
+		p.ContextName = "vlan-" + id
+		return p, nil
+	}
+	if len(p.Community) > 0 {
+		p.Community += "@" + id
+	}
+	if len(p.Communities) > 0 {
+		communities := make([]string, len(p.Communities))
+		for i, c := range p.Communities {
+			communities[i] = c + "@" + id
+		}
+		p.Communities = communities
+	}
+	return p, nil
+}
+
+// WalkVLAN returns the values under rootOID in the vlan's context,
+// using a client made for the purpose (see VLANProfile and Walk)
+func WalkVLAN(p Profile, vlan int, rootOID string, opts ...WalkOption) (map[string]interface{}, error) {
+	p, err := VLANProfile(p, vlan)
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewClient(p)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Conn.Close()
+	return Walk(client, rootOID, opts...)
+}
@@ -0,0 +1,35 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"testing"
+)
+
+func TestVLANProfile(t *testing.T) {
+	p, err := VLANProfile(Profile{Community: "public", Communities: []string{"a", "b"}}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Community != "public@10" || p.Communities[1] != "b@10" {
+		t.Errorf("unexpected communities: %s %v", p.Community, p.Communities)
+	}
+
+	base := Profile{Version: "3", AuthUser: "admin"}
+	p, err = VLANProfile(base, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ContextName != "vlan-20" {
+		t.Errorf("expected vlan-20 context, got %q", p.ContextName)
+	}
+	if base.ContextName != "" {
+		t.Error("base profile was modified")
+	}
+
+	if _, err := VLANProfile(Profile{Community: "public"}, 4095); err == nil {
+		t.Error("expected error for invalid vlan")
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// WaitFor gets oid every interval until pred returns true for its value,
+// which is returned. Timeouts and missing values (e.g., while a device
+// reboots or an entry is created) are polled through, while other errors
+// are returned at once. If ctx ends first the last value seen is
+// returned with the context's error.
+func WaitFor(ctx context.Context, client *gosnmp.GoSNMP, oid string, pred func(interface{}) bool, interval time.Duration) (interface{}, error) {
+	oid, err := getOID(oid)
+	if err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var last interface{}
+	for {
+		value, found, err := waitGet(ctx, client, oid)
+		switch {
+		case err != nil && !DefaultRetryable(err):
+			if ctx.Err() != nil {
+				return last, ctx.Err()
+			}
+			return last, err
+		case found:
+			last = value
+			if pred(value) {
+				return value, nil
+			}
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return last, ctx.Err()
+		}
+	}
+}
+
+// waitGet gets the value of oid, reporting whether the agent has it
+func waitGet(ctx context.Context, client *gosnmp.GoSNMP, oid string) (interface{}, bool, error) {
+	if err := connected(client); err != nil {
+		return nil, false, err
+	}
+	var packet *gosnmp.SnmpPacket
+	err := withContext(ctx, client, func() error {
+		var err error
+		if packet, err = client.Get([]string{oid}); err == nil {
+			err = packetError(client, packet)
+		}
+		return err
+	})
+	recordStats(client, err)
+	if err != nil {
+		return nil, false, err
+	}
+	switch {
+	case packet.Error == gosnmp.NoSuchName:
+		return nil, false, nil
+	case packet.Error != gosnmp.NoError:
+		return nil, false, errors.Errorf("get %s failed: %s", oid, packet.Error)
+	case len(packet.Variables) != 1:
+		return nil, false, nil
+	}
+	pdu := packet.Variables[0]
+	switch pdu.Type {
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView:
+		return nil, false, nil
+	}
+	value, err := pduType(pdu)
+	return value, err == nil, err
+}
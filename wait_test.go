@@ -0,0 +1,45 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+func TestWaitFor(t *testing.T) {
+	oper := ifOperStatus + ".3"
+	status := func(v int64) []Record {
+		return []Record{{OID: oper, Type: int32(gosnmp.Integer), IntVal: v}}
+	}
+	client := replayAgent(t,
+		Exchange{PDUType: gosnmp.GetRequest, OIDs: []string{oper}, Response: []Record{{OID: oper, Type: int32(gosnmp.NoSuchInstance)}}},
+		Exchange{PDUType: gosnmp.GetRequest, OIDs: []string{oper}, Response: status(int64(IfDown))},
+		Exchange{PDUType: gosnmp.GetRequest, OIDs: []string{oper}, Response: status(int64(IfUp))},
+	)
+
+	up := func(v interface{}) bool { return v == int(IfUp) }
+	value, err := WaitFor(context.Background(), client, oper, up, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != int(IfUp) {
+		t.Errorf("expected up, got %v", value)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	never := func(interface{}) bool { return false }
+	value, err = WaitFor(ctx, client, oper, never, time.Millisecond)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if value != int(IfUp) {
+		t.Errorf("expected last value with the error, got %v", value)
+	}
+}
@@ -0,0 +1,504 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/soniah/gosnmp"
+)
+
+// walkStrategyKey is the key of a client's walk strategy in its Context
+type walkStrategyKey struct{}
+
+// SetWalkStrategy sets how the walk helpers walk with the client:
+// "bulk" for GETBULK, "getnext" for GETNEXT (e.g., for agents with broken
+// GETBULK support) or "auto" (or "") for GETBULK unless the client is v1.
+// The strategy is kept in the client's Context, so replacing the Context
+// resets it to "auto" (the helpers taking a context leave it be).
+func SetWalkStrategy(client *gosnmp.GoSNMP, strategy string) error {
+	switch strategy {
+	case "", "auto":
+		if walkStrategy(client) == "" {
+			return nil
+		}
+		strategy = ""
+	case "bulk":
+		if client.Version == gosnmp.Version1 {
+			return errors.New("snmp v1 does not support bulk walks")
+		}
+	case "getnext":
+	default:
+		return errors.Errorf("invalid walk strategy %q", strategy)
+	}
+	setClientValue(client, walkStrategyKey{}, strategy)
+	return nil
+}
+
+// walkStrategy returns the strategy set for the client, "" for auto
+func walkStrategy(client *gosnmp.GoSNMP) string {
+	if client.Context == nil {
+		return ""
+	}
+	strategy, _ := client.Context.Value(walkStrategyKey{}).(string)
+	return strategy
+}
+
+// useBulk reports whether the client walks with GETBULK
+func useBulk(client *gosnmp.GoSNMP) bool {
+	if strategy := walkStrategy(client); strategy != "" {
+		return strategy == "bulk"
+	}
+	// snmp v1 doesn't support bulkwalk
+	return client.Version != gosnmp.Version1
+}
+
+// walkFunc returns the walk method appropriate for the client's version
+// and walk strategy
+func walkFunc(client *gosnmp.GoSNMP, opts ...WalkOption) func(string, gosnmp.WalkFunc) error {
+	o := newWalkOptions(opts)
+	calibrate := o.calibrate > 0 && o.maxReps == 0
+	if o.maxReps == 0 {
+		o.maxReps = client.MaxRepetitions
+	}
+	walk := func(oid string, fn gosnmp.WalkFunc) error {
+		o := o
+		var getter bulkGetter = sizedGetter{client}
+		if calibrate {
+			reps, probe, err := calibratedRepetitions(getter, capabilityKey(client), oid, o.calibrate)
+			if err != nil {
+				return err
+			}
+			if reps > 0 {
+				o.maxReps = reps
+			}
+			if probe != nil {
+				getter = &primedGetter{bulkGetter: getter, first: probe}
+			}
+		}
+		return bulkWalk(getter, oid, o, fn)
+	}
+	if !useBulk(client) {
+		walk = func(oid string, fn gosnmp.WalkFunc) error {
+			return nextWalk(client, oid, o, fn)
+		}
+	}
+	return func(oid string, fn gosnmp.WalkFunc) error {
+		if err := connected(client); err != nil {
+			return err
+		}
+		err := walk(oid, fn)
+		recordStats(client, err)
+		return err
+	}
+}
+
+// getter is the subset of a client needed to get a leaf
+type getter interface {
+	Get(oids []string) (*gosnmp.SnmpPacket, error)
+}
+
+// bulkGetter is the subset of a client needed for a bulk walk
+type bulkGetter interface {
+	getter
+	GetBulk(oids []string, nonRepeaters, maxRepetitions uint8) (*gosnmp.SnmpPacket, error)
+}
+
+// sizedGetter records the size of each GETBULK response in the client's stats
+type sizedGetter struct {
+	*gosnmp.GoSNMP
+}
+
+func (g sizedGetter) GetBulk(oids []string, nonRepeaters, maxRepetitions uint8) (*gosnmp.SnmpPacket, error) {
+	packet, err := g.GoSNMP.GetBulk(oids, nonRepeaters, maxRepetitions)
+	if err == nil {
+		recordResponse(g.GoSNMP, packet)
+	}
+	return packet, err
+}
+
+// bulkWalk walks the subtree at rootOID using GETBULK. Unlike gosnmp's
+// BulkWalk, every varbind of a response is processed: those that are
+// EndOfMibView or NoSuch* are skipped, and the walk ends when none of a
+// response's varbinds are in the subtree. A response stepping past the
+// subtree or ending with EndOfMibView also ends the walk, rather than
+// making a request that can return nothing more.
+//
+// The walk is configured by o: its max-repetitions and the OID order
+// enforced (see OIDOrder). A varbind repeating the OID before it is
+// skipped, rather than failing the order, and noted as a duplicate.
+func bulkWalk(client bulkGetter, rootOID string, o walkOptions, fn gosnmp.WalkFunc) error {
+	if !strings.HasPrefix(rootOID, ".") {
+		rootOID = "." + rootOID
+	}
+	maxReps := o.maxReps
+	if maxReps == 0 {
+		maxReps = defaultRepetitions
+	}
+	compare := o.compare
+	if compare == nil {
+		compare = CompareOIDs
+	}
+	oid, prefix := rootOID, rootOID+"."
+	for first := true; ; first = false {
+		packet, err := client.GetBulk([]string{oid}, 0, maxReps)
+		if err != nil {
+			return err
+		}
+		if packet.Error == gosnmp.NoSuchName || len(packet.Variables) == 0 {
+			return nil
+		}
+		inside := 0
+		for i, pdu := range packet.Variables {
+			switch pdu.Type {
+			case gosnmp.EndOfMibView, gosnmp.NoSuchObject, gosnmp.NoSuchInstance:
+				continue
+			}
+			if !strings.HasPrefix(pdu.Name, prefix) {
+				// the root may be a leaf, which a GETBULK steps past
+				if first && i == 0 {
+					return getLeaf(client, rootOID, fn)
+				}
+				return nil
+			}
+			if pdu.Name == oid {
+				// a misbehaving agent repeating itself, which is not out of order
+				o.noteDuplicate(pdu.Name)
+				continue
+			}
+			if compare(pdu.Name, oid) <= 0 {
+				return errors.Errorf("OID not increasing: %s", pdu.Name)
+			}
+			if err := fn(pdu); err != nil {
+				return err
+			}
+			oid = pdu.Name
+			inside++
+		}
+		// nothing follows the end of the agent's MIB
+		if inside == 0 || packet.Variables[len(packet.Variables)-1].Type == gosnmp.EndOfMibView {
+			return nil
+		}
+	}
+}
+
+// nextGetter is the subset of a client needed for a GETNEXT walk
+type nextGetter interface {
+	getter
+	GetNext(oids []string) (*gosnmp.SnmpPacket, error)
+}
+
+// nextWalk walks the subtree at rootOID using GETNEXT, for v1 agents and
+// those walked with the "getnext" strategy, enforcing the same OID order
+// as bulkWalk (see OIDOrder). An agent answering with the OID requested
+// has it noted as a duplicate, and ends the walk.
+func nextWalk(client nextGetter, rootOID string, o walkOptions, fn gosnmp.WalkFunc) error {
+	if !strings.HasPrefix(rootOID, ".") {
+		rootOID = "." + rootOID
+	}
+	compare := o.compare
+	if compare == nil {
+		compare = CompareOIDs
+	}
+	oid, prefix := rootOID, rootOID+"."
+	for first := true; ; first = false {
+		packet, err := client.GetNext([]string{oid})
+		if err != nil {
+			return err
+		}
+		// v1 agents report the end of their MIB as noSuchName
+		if packet.Error == gosnmp.NoSuchName || len(packet.Variables) == 0 {
+			return nil
+		}
+		if packet.Error != gosnmp.NoError {
+			return errors.Errorf("walk of %s failed at %s: %s", rootOID, oid, packet.Error)
+		}
+		pdu := packet.Variables[0]
+		switch pdu.Type {
+		case gosnmp.EndOfMibView, gosnmp.NoSuchObject, gosnmp.NoSuchInstance:
+			return nil
+		}
+		if !strings.HasPrefix(pdu.Name, prefix) {
+			// the root may be a leaf, which a GETNEXT steps past
+			if first {
+				return getLeaf(client, rootOID, fn)
+			}
+			return nil
+		}
+		if pdu.Name == oid {
+			// asking again would only repeat it, so the walk can go no further
+			o.noteDuplicate(pdu.Name)
+			return nil
+		}
+		if compare(pdu.Name, oid) <= 0 {
+			return errors.Errorf("OID not increasing: %s", pdu.Name)
+		}
+		if err := fn(pdu); err != nil {
+			return err
+		}
+		oid = pdu.Name
+	}
+}
+
+// getLeaf applies fn to the value of the leaf oid, if it exists
+func getLeaf(client getter, oid string, fn gosnmp.WalkFunc) error {
+	packet, err := client.Get([]string{oid})
+	if err != nil {
+		return err
+	}
+	for _, pdu := range packet.Variables {
+		switch pdu.Type {
+		case gosnmp.EndOfMibView, gosnmp.NoSuchObject, gosnmp.NoSuchInstance:
+			continue
+		}
+		if pdu.Name == oid {
+			return fn(pdu)
+		}
+	}
+	return nil
+}
+
+// ErrWalkTooLarge is returned when a walk exceeds its MaxBytes budget
+var ErrWalkTooLarge = errors.New("walk exceeded maximum response size")
+
+// WalkOption adjusts the behavior of the walk helpers
+type WalkOption func(*walkOptions)
+
+type walkOptions struct {
+	maxBytes  int
+	maxReps   uint8
+	compare   func(a, b string) int
+	enums     bool
+	calibrate int // target response size
+	duplicate func(oid string)
+}
+
+// noteDuplicate reports an OID the agent repeated, if anything is listening
+func (o walkOptions) noteDuplicate(oid string) {
+	if o.duplicate != nil {
+		o.duplicate(oid)
+	}
+}
+
+// MaxBytes aborts a walk once the total decoded size of the
+// values received exceeds n bytes (0 for no limit)
+func MaxBytes(n int) WalkOption {
+	return func(o *walkOptions) {
+		o.maxBytes = n
+	}
+}
+
+// MaxRepetitions overrides the client's GETBULK max-repetitions for the walk
+func MaxRepetitions(n uint8) WalkOption {
+	return func(o *walkOptions) {
+		o.maxReps = n
+	}
+}
+
+// AdaptiveRepetitions has no effect.
+//
+// Deprecated: walks end with the response that leaves the subtree, so
+// no request is made past the end of a table for it to shrink.
+func AdaptiveRepetitions() WalkOption {
+	return func(o *walkOptions) {}
+}
+
+// OIDOrder replaces the comparison used to ensure a walk's OIDs are
+// increasing (CompareOIDs), for nonconforming agents whose order differs.
+// cmp returns a negative number, zero or a positive number as a sorts
+// before, with or after b; a walk fails if an OID does not sort after
+// the one before it, so that it cannot loop. It applies to GETBULK and
+// GETNEXT walks alike.
+func OIDOrder(cmp func(a, b string) int) WalkOption {
+	return func(o *walkOptions) {
+		o.compare = cmp
+	}
+}
+
+func newWalkOptions(opts []WalkOption) walkOptions {
+	var o walkOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// pduSize approximates the decoded size of a pdu
+func pduSize(pdu gosnmp.SnmpPDU) int {
+	size := len(pdu.Name)
+	switch v := pdu.Value.(type) {
+	case []byte:
+		size += len(v)
+	case string:
+		size += len(v)
+	default:
+		size += 8
+	}
+	return size
+}
+
+// Walk returns all values found under rootOID, keyed by OID
+func Walk(client *gosnmp.GoSNMP, rootOID string, opts ...WalkOption) (map[string]interface{}, error) {
+	return WalkFilter(client, rootOID, nil, opts...)
+}
+
+// WalkFilter returns the values found under rootOID for which keep returns true.
+// Values are filtered as they are received so the full table is never held.
+// A nil keep func retains all values.
+//
+// If the walk is aborted the values received so far are returned with the error.
+func WalkFilter(client *gosnmp.GoSNMP, rootOID string, keep func(oid string, value interface{}) bool, opts ...WalkOption) (map[string]interface{}, error) {
+	o := newWalkOptions(opts)
+	oid, err := getOID(rootOID)
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[string]interface{})
+	size := 0
+	fn := func(pdu gosnmp.SnmpPDU) error {
+		if size += pduSize(pdu); o.maxBytes > 0 && size > o.maxBytes {
+			return ErrWalkTooLarge
+		}
+		value, err := pduType(pdu)
+		if err != nil {
+			return err
+		}
+		if _, dupe := results[pdu.Name]; dupe {
+			// misbehaving agent, keep the first value seen
+			return nil
+		}
+		if keep == nil || keep(pdu.Name, value) {
+			results[pdu.Name] = value
+		}
+		return nil
+	}
+	if err := walkFunc(client, opts...)(oid, fn); err != nil || size > 0 {
+		return results, err
+	}
+	// gosnmp ends a walk quietly on a report, so find out if it was one
+	return results, probeReport(client)
+}
+
+// Varbind is an OID and its decoded value
+type Varbind struct {
+	OID   string
+	Value interface{}
+}
+
+// WalkResult holds the values of a walk in the order received
+type WalkResult struct {
+	Varbinds      []Varbind
+	DuplicateOIDs []string // OIDs the agent returned more than once
+}
+
+// WalkOrdered returns the values found under rootOID in the order received.
+// OIDs repeated by the agent are only included once, and are listed in
+// DuplicateOIDs as a sign the device is misbehaving.
+func WalkOrdered(client *gosnmp.GoSNMP, rootOID string, opts ...WalkOption) (WalkResult, error) {
+	var result WalkResult
+	o := newWalkOptions(opts)
+	oid, err := getOID(rootOID)
+	if err != nil {
+		return result, err
+	}
+	seen := make(map[string]struct{})
+	size := 0
+	fn := func(pdu gosnmp.SnmpPDU) error {
+		if size += pduSize(pdu); o.maxBytes > 0 && size > o.maxBytes {
+			return ErrWalkTooLarge
+		}
+		if _, dupe := seen[pdu.Name]; dupe {
+			result.DuplicateOIDs = append(result.DuplicateOIDs, pdu.Name)
+			return nil
+		}
+		seen[pdu.Name] = struct{}{}
+		value, err := pduType(pdu)
+		if err != nil {
+			return err
+		}
+		result.Varbinds = append(result.Varbinds, Varbind{pdu.Name, value})
+		return nil
+	}
+	// repeats of the OID before are skipped by the walk itself
+	opts = append(opts[:len(opts):len(opts)], func(o *walkOptions) {
+		o.duplicate = func(oid string) {
+			result.DuplicateOIDs = append(result.DuplicateOIDs, oid)
+		}
+	})
+	if err := walkFunc(client, opts...)(oid, fn); err != nil || size > 0 {
+		return result, err
+	}
+	return result, probeReport(client)
+}
+
+// WalkAppend appends the values found under rootOID to *buf in the order
+// received, for pollers that walk often enough for allocations to matter.
+// Reusing the buffer between walks (e.g., passing buf[:0]) avoids the
+// map and slice growth of Walk and WalkOrdered. An OID the agent repeats
+// straight after itself is only appended once; other duplicates are not
+// detected, other than by the walk failing on OIDs not increasing.
+func WalkAppend(client *gosnmp.GoSNMP, rootOID string, buf *[]Varbind, opts ...WalkOption) error {
+	o := newWalkOptions(opts)
+	oid, err := getOID(rootOID)
+	if err != nil {
+		return err
+	}
+	size := 0
+	fn := func(pdu gosnmp.SnmpPDU) error {
+		if size += pduSize(pdu); o.maxBytes > 0 && size > o.maxBytes {
+			return ErrWalkTooLarge
+		}
+		value, err := pduType(pdu)
+		if err != nil {
+			return err
+		}
+		*buf = append(*buf, Varbind{pdu.Name, value})
+		return nil
+	}
+	if err := walkFunc(client, opts...)(oid, fn); err != nil || size > 0 {
+		return err
+	}
+	return probeReport(client)
+}
+
+// SupportsBulk reports whether the device gives a sane response to a small GETBULK.
+// Devices that return an error status, no values, or values out of order are
+// considered not to support it. An error is returned only if the request failed.
+// The result is cached if a capability cache is set (see SetCapabilityCache).
+func SupportsBulk(client *gosnmp.GoSNMP) (bool, error) {
+	return cachedCapability(client, CapBulk, func() (bool, error) {
+		return supportsBulk(client)
+	})
+}
+
+func supportsBulk(client *gosnmp.GoSNMP) (bool, error) {
+	if client.Version == gosnmp.Version1 {
+		return false, nil
+	}
+	if err := connected(client); err != nil {
+		return false, err
+	}
+	const system = ".1.3.6.1.2.1.1"
+	packet, err := client.GetBulk([]string{system}, 0, 4)
+	if err != nil {
+		return false, err
+	}
+	if packet.Error != gosnmp.NoError || len(packet.Variables) == 0 {
+		return false, nil
+	}
+	prior := system
+	for _, pdu := range packet.Variables {
+		switch pdu.Type {
+		case gosnmp.EndOfMibView, gosnmp.NoSuchObject, gosnmp.NoSuchInstance:
+			return false, nil
+		}
+		if compareOIDs(pdu.Name, prior) <= 0 {
+			return false, nil
+		}
+		prior = pdu.Name
+	}
+	return true, nil
+}
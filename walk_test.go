@@ -0,0 +1,270 @@
+// Copyright 2016 Paul Stuart. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package snmputil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+// bulkAgent replies to requests with canned responses keyed by requested OID
+type bulkAgent map[string][]gosnmp.SnmpPDU
+
+func (a bulkAgent) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	return &gosnmp.SnmpPacket{Variables: a["get"+oids[0]]}, nil
+}
+
+func (a bulkAgent) GetBulk(oids []string, nonRepeaters, maxRepetitions uint8) (*gosnmp.SnmpPacket, error) {
+	return &gosnmp.SnmpPacket{Variables: a[oids[0]]}, nil
+}
+
+// GetNext answers with the first varbind a GETBULK would
+func (a bulkAgent) GetNext(oids []string) (*gosnmp.SnmpPacket, error) {
+	return &gosnmp.SnmpPacket{Variables: a[oids[0]][:1]}, nil
+}
+
+func intPDU(oid string, v int) gosnmp.SnmpPDU {
+	return gosnmp.SnmpPDU{Name: oid, Type: gosnmp.Integer, Value: v}
+}
+
+func walkNames(t *testing.T, agent bulkAgent, root string) []string {
+	var names []string
+	err := bulkWalk(agent, root, walkOptions{}, func(pdu gosnmp.SnmpPDU) error {
+		names = append(names, pdu.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestBulkWalk(t *testing.T) {
+	const root = ".1.3.6.1.2.1.2.2.1"
+	end := gosnmp.SnmpPDU{Name: root + ".3.2", Type: gosnmp.EndOfMibView}
+	agent := bulkAgent{
+		root: {
+			intPDU(root+".1.1", 1),
+			intPDU(root+".1.2", 2),
+			end,
+			intPDU(root+".3.1", 6),
+			intPDU(root+".3.2", 6),
+		},
+		root + ".3.2": {
+			intPDU(root+".4.1", 1500),
+			{Name: root + ".4.2", Type: gosnmp.EndOfMibView},
+			intPDU(".1.3.6.1.2.1.2.2.2.1", 0),
+		},
+		root + ".4.1": {
+			{Name: root + ".4.1", Type: gosnmp.EndOfMibView},
+		},
+	}
+	expect := []string{root + ".1.1", root + ".1.2", root + ".3.1", root + ".3.2", root + ".4.1"}
+	if got := walkNames(t, agent, root); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+}
+
+func TestBulkWalkLeaf(t *testing.T) {
+	const leaf = ".1.3.6.1.2.1.1.5.0"
+	agent := bulkAgent{
+		leaf:         {intPDU(".1.3.6.1.2.1.1.6.0", 0)},
+		"get" + leaf: {intPDU(leaf, 1)},
+	}
+	if got := walkNames(t, agent, leaf); !reflect.DeepEqual(got, []string{leaf}) {
+		t.Errorf("expected leaf value, got %v", got)
+	}
+}
+
+func TestBulkWalkNotIncreasing(t *testing.T) {
+	const root = ".1.3.6.1.2.1.2.2.1"
+	agent := bulkAgent{
+		root:          {intPDU(root+".1.2", 1)},
+		root + ".1.2": {intPDU(root+".1.1", 2)},
+	}
+	err := bulkWalk(agent, root, walkOptions{}, func(gosnmp.SnmpPDU) error { return nil })
+	if err == nil {
+		t.Error("expected error for non-increasing OIDs")
+	}
+}
+
+func TestOIDOrder(t *testing.T) {
+	const root = ".1.3.6.1.2.1.2.2.1.2"
+	// an agent sorting its indexes as strings
+	agent := bulkAgent{
+		root:         {intPDU(root+".1", 1), intPDU(root+".10", 10), intPDU(root+".2", 2)},
+		root + ".1":  {intPDU(root+".10", 10)},
+		root + ".2":  {intPDU(root+".3", 3)},
+		root + ".3":  {{Name: root + ".3", Type: gosnmp.EndOfMibView}},
+		root + ".10": {intPDU(root+".2", 2)},
+	}
+	fn := func(gosnmp.SnmpPDU) error { return nil }
+	if err := bulkWalk(agent, root, walkOptions{}, fn); err == nil {
+		t.Error("expected error for the default order")
+	}
+	if err := bulkWalk(agent, root, newWalkOptions([]WalkOption{OIDOrder(strings.Compare)}), fn); err != nil {
+		t.Errorf("unexpected error with custom order: %v", err)
+	}
+
+	// as do GETNEXT walks
+	if err := nextWalk(agent, root, walkOptions{}, fn); err == nil {
+		t.Error("expected error for the default order walking with GETNEXT")
+	}
+	var names []string
+	err := nextWalk(agent, root, newWalkOptions([]WalkOption{OIDOrder(strings.Compare)}), func(pdu gosnmp.SnmpPDU) error {
+		names = append(names, pdu.Name)
+		return nil
+	})
+	if err != nil || len(names) != 4 {
+		t.Errorf("expected 4 values with custom order walking with GETNEXT, got %v: %v", names, err)
+	}
+}
+
+// repsAgent is a bulkAgent noting the max-repetitions of each request
+type repsAgent struct {
+	bulkAgent
+	reps []uint8
+}
+
+func (a *repsAgent) GetBulk(oids []string, nonRepeaters, maxRepetitions uint8) (*gosnmp.SnmpPacket, error) {
+	a.reps = append(a.reps, maxRepetitions)
+	return a.bulkAgent.GetBulk(oids, nonRepeaters, maxRepetitions)
+}
+
+func TestBulkWalkEnd(t *testing.T) {
+	const root = ".1.3.6.1.2.1.2.2.1.2"
+	end := gosnmp.SnmpPDU{Name: root + ".7", Type: gosnmp.EndOfMibView}
+	past := intPDU(".1.3.6.1.2.1.2.2.1.3.1", 6)
+	for _, last := range [][]gosnmp.SnmpPDU{
+		{intPDU(root+".6", 6), intPDU(root+".7", 7), end, end, end},
+		{intPDU(root+".6", 6), intPDU(root+".7", 7), past, past, past},
+	} {
+		agent := &repsAgent{bulkAgent: bulkAgent{
+			root:        {intPDU(root+".1", 1), intPDU(root+".2", 2), intPDU(root+".3", 3), intPDU(root+".4", 4), intPDU(root+".5", 5)},
+			root + ".5": last,
+		}}
+		var count int
+		err := bulkWalk(agent, root, walkOptions{maxReps: 5}, func(gosnmp.SnmpPDU) error {
+			count++
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 7 {
+			t.Errorf("expected 7 values, got %d", count)
+		}
+		// the response leaving the subtree ends the walk
+		if !reflect.DeepEqual(agent.reps, []uint8{5, 5}) {
+			t.Errorf("expected 2 requests, got %v", agent.reps)
+		}
+	}
+}
+
+func TestWalkAppend(t *testing.T) {
+	client := replayAgent(t, ifDescrTranscript)
+
+	buf := make([]Varbind, 0, 8)
+	for i := 0; i < 2; i++ {
+		buf = buf[:0]
+		if err := WalkAppend(client, ifDescr, &buf); err != nil {
+			t.Fatal(err)
+		}
+		if len(buf) != 2 || buf[1].Value != "eth0" {
+			t.Fatalf("unexpected varbinds: %v", buf)
+		}
+		if cap(buf) != 8 {
+			t.Errorf("expected buffer to be reused, cap is %d", cap(buf))
+		}
+	}
+}
+
+func TestWalkOrderedDuplicates(t *testing.T) {
+	lo := Record{OID: ifDescr + ".1", Type: int32(gosnmp.OctetString), BytesVal: []byte("lo")}
+	eth0 := Record{OID: ifDescr + ".2", Type: int32(gosnmp.OctetString), BytesVal: []byte("eth0")}
+	client := replayAgent(t, Exchange{
+		PDUType:        gosnmp.GetBulkRequest,
+		OIDs:           []string{ifDescr},
+		MaxRepetitions: defaultRepetitions,
+		Response:       []Record{lo, lo, eth0, {OID: ifOperStatus + ".1", Type: int32(gosnmp.Integer), IntVal: 1}},
+	})
+	result, err := WalkOrdered(client, ifDescr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Varbinds) != 2 || result.Varbinds[1].Value != "eth0" {
+		t.Errorf("unexpected varbinds: %v", result.Varbinds)
+	}
+	if !reflect.DeepEqual(result.DuplicateOIDs, []string{ifDescr + ".1"}) {
+		t.Errorf("unexpected duplicates: %v", result.DuplicateOIDs)
+	}
+
+	// an agent answering a GETNEXT with the OID requested ends the walk
+	client = replayAgent(t,
+		Exchange{PDUType: gosnmp.GetNextRequest, OIDs: []string{ifDescr}, Response: []Record{lo}},
+		Exchange{PDUType: gosnmp.GetNextRequest, OIDs: []string{ifDescr + ".1"}, Response: []Record{lo}},
+	)
+	if err := SetWalkStrategy(client, "getnext"); err != nil {
+		t.Fatal(err)
+	}
+	result, err = WalkOrdered(client, ifDescr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Varbinds) != 1 || !reflect.DeepEqual(result.DuplicateOIDs, []string{ifDescr + ".1"}) {
+		t.Errorf("unexpected result walking with GETNEXT: %+v", result)
+	}
+}
+
+func TestWalkStrategy(t *testing.T) {
+	str := func(oid, s string) []Record {
+		return []Record{{OID: oid, Type: int32(gosnmp.OctetString), BytesVal: []byte(s)}}
+	}
+	rp := replayer(t,
+		Exchange{PDUType: gosnmp.GetNextRequest, OIDs: []string{ifDescr}, Response: str(ifDescr+".1", "lo")},
+		Exchange{PDUType: gosnmp.GetNextRequest, OIDs: []string{ifDescr + ".1"}, Response: str(ifDescr+".2", "eth0")},
+		Exchange{PDUType: gosnmp.GetNextRequest, OIDs: []string{ifDescr + ".2"}, Response: []Record{{OID: ifOperStatus + ".1", Type: int32(gosnmp.Integer), IntVal: 1}}},
+	)
+	client := replayClient(t, rp)
+	defer client.Conn.Close()
+	if err := SetWalkStrategy(client, "getnext"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetWalkStrategy(client, "auto")
+
+	values, err := Walk(client, ifDescr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[ifDescr+".2"] != "eth0" {
+		t.Errorf("unexpected values: %v", values)
+	}
+	if rp.Misses() != 0 {
+		t.Errorf("expected only GETNEXT requests, got %d unexpected", rp.Misses())
+	}
+
+	if err := SetWalkStrategy(client, "auto"); err != nil || !useBulk(client) {
+		t.Errorf("expected auto to walk with GETBULK again: %v", err)
+	}
+	pinned, err := NewClient(Profile{Host: testHost, WalkStrategy: "getnext"}, LazyConnect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if useBulk(pinned) {
+		t.Error("expected the profile's walk strategy to be kept by the client")
+	}
+
+	for _, p := range []Profile{
+		{Host: testHost, Version: "1", WalkStrategy: "bulk"},
+		{Host: testHost, Version: "2c", WalkStrategy: "bogus"},
+	} {
+		if _, err := NewClient(p, LazyConnect()); err == nil {
+			t.Errorf("expected error for %s walk strategy with v%s", p.WalkStrategy, p.Version)
+		}
+	}
+}